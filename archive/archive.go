@@ -0,0 +1,161 @@
+// Package archive stores fetched lyrics
+// on the local disk, one file per spotify track.
+package archive
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// stateFile holds the archive sync state.
+const stateFile = "sync.json"
+
+// Record is a single archived track.
+type Record struct {
+	ID      string
+	Artist  string
+	Title   string
+	Album   string
+	AddedAt time.Time
+	// Lyrics are empty if they couldn't be fetched.
+	Lyrics string
+	// Language is the ISO 639-1 code of the
+	// lyrics language, empty if unknown.
+	Language  string `json:",omitempty"`
+	FetchedAt time.Time
+	// Error is the reason the lyrics are missing.
+	Error string `json:",omitempty"`
+}
+
+// Missing reports whether the lyrics of the
+// record couldn't be fetched.
+func (r Record) Missing() bool {
+	return r.Lyrics == ""
+}
+
+// State is the persisted state of the last sync.
+type State struct {
+	// LastAddedAt is the time the newest synced
+	// track was added to the library.
+	LastAddedAt time.Time
+}
+
+// Archive is a directory of archived lyrics.
+// It's safe for concurrent use as long as
+// each record is written by one goroutine.
+type Archive struct {
+	Dir string
+}
+
+// Open returns the archive in the given directory
+// creating the directory if needed.
+func Open(dir string) (*Archive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Archive{Dir: dir}, nil
+}
+
+func (a *Archive) recordPath(id string) string {
+	return filepath.Join(a.Dir, id+".json")
+}
+
+// Has reports whether the track with the
+// given id is already archived.
+func (a *Archive) Has(id string) bool {
+	_, err := os.Stat(a.recordPath(id))
+	return err == nil
+}
+
+// Get returns archived record of the track with the given id.
+func (a *Archive) Get(id string) (Record, error) {
+	var r Record
+	err := readJSON(a.recordPath(id), &r)
+	return r, err
+}
+
+// Put archives the record overwriting
+// the previous one with the same id.
+func (a *Archive) Put(r Record) error {
+	return writeJSON(a.recordPath(r.ID), r)
+}
+
+// Records returns all of the archived records.
+func (a *Archive) Records() ([]Record, error) {
+	files, err := ioutil.ReadDir(a.Dir)
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() || name == stateFile || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		r, err := a.Get(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// RecordsIn returns the archived records
+// of the lyrics in the given language.
+func (a *Archive) RecordsIn(lang string) ([]Record, error) {
+	all, err := a.Records()
+	if err != nil {
+		return nil, err
+	}
+	records := []Record{}
+	for _, r := range all {
+		if r.Language == lang {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+// State returns the state of the last sync.
+// If the archive was never synced zero State is returned.
+func (a *Archive) State() (State, error) {
+	var s State
+	err := readJSON(filepath.Join(a.Dir, stateFile), &s)
+	if os.IsNotExist(err) {
+		return State{}, nil
+	}
+	return s, err
+}
+
+// SetState persists the sync state.
+func (a *Archive) SetState(s State) error {
+	return writeJSON(filepath.Join(a.Dir, stateFile), s)
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON writes v to the temporary file first
+// and then renames it, so interrupted writes
+// don't leave broken files behind.
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
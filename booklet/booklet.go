@@ -0,0 +1,137 @@
+// Package booklet compiles lyrics of an ordered
+// list of songs into a single document.
+// Supported output formats are Markdown, HTML and EPUB.
+package booklet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+// ErrUnknownFormat is returned by ParseFormat
+// if the format name is not recognized.
+var ErrUnknownFormat = errors.New("unknown booklet format")
+
+// Format is the output format of the booklet.
+type Format string
+
+// Supported booklet formats.
+const (
+	Markdown Format = "md"
+	HTML     Format = "html"
+	EPUB     Format = "epub"
+)
+
+// ParseFormat returns Format matching the given
+// name or file extension, for example "md",
+// "markdown" or ".epub".
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "md", "markdown":
+		return Markdown, nil
+	case "html", "htm":
+		return HTML, nil
+	case "epub":
+		return EPUB, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Entry is a single song of the booklet.
+type Entry struct {
+	lyrics.SongInfo
+	// Err is the reason the lyrics couldn't
+	// be fetched, nil if they were.
+	Err error
+}
+
+// Missing reports whether the lyrics of the
+// entry couldn't be fetched.
+func (e Entry) Missing() bool {
+	return e.Err != nil
+}
+
+// Booklet is a titled list of songs with their lyrics.
+// Entries keep the order of the source list.
+type Booklet struct {
+	Title   string
+	Entries []Entry
+}
+
+// Compile fetches lyrics for every song using the
+// given Fetcher and returns the booklet.
+// Songs which lyrics couldn't be fetched are kept
+// in the booklet and reported by Missing.
+//
+// If progress is not nil it is called after
+// every fetched song.
+func Compile(title string, songs []lyrics.SongInfo, f lyrics.Fetcher, progress func(done int, e Entry)) *Booklet {
+	b := &Booklet{
+		Title:   title,
+		Entries: make([]Entry, 0, len(songs)),
+	}
+	for i, song := range songs {
+		e := Entry{SongInfo: song}
+		e.Err = e.FetchLyrics(f)
+		if e.Err == nil && strings.TrimSpace(e.Lyrics) == "" {
+			e.Err = lyrics.ErrLyricsNotFound
+		}
+		b.Entries = append(b.Entries, e)
+		if progress != nil {
+			progress(i+1, e)
+		}
+	}
+	return b
+}
+
+// Missing returns entries which lyrics
+// couldn't be fetched, in the booklet order.
+func (b *Booklet) Missing() []Entry {
+	missing := []Entry{}
+	for _, e := range b.Entries {
+		if e.Missing() {
+			missing = append(missing, e)
+		}
+	}
+	return missing
+}
+
+// Summary returns human readable summary
+// of the booklet listing songs with missing lyrics.
+func (b *Booklet) Summary() string {
+	missing := b.Missing()
+	sb := strings.Builder{}
+	fmt.Fprintf(&sb, "Fetched lyrics for %d of %d songs.\n",
+		len(b.Entries)-len(missing), len(b.Entries))
+	if len(missing) > 0 {
+		sb.WriteString("Missing lyrics:\n")
+		for _, e := range missing {
+			fmt.Fprintf(&sb, "\t%s - %s: %s\n", e.Author, e.Title, e.Err)
+		}
+	}
+	return sb.String()
+}
+
+// Write writes the booklet to w in the given format.
+func (b *Booklet) Write(w io.Writer, format Format) error {
+	switch format {
+	case Markdown:
+		return b.writeMarkdown(w)
+	case HTML:
+		return b.writeHTML(w)
+	case EPUB:
+		return b.writeEPUB(w)
+	}
+	return ErrUnknownFormat
+}
+
+// notAvailable is written in place of the missing lyrics.
+const notAvailable = "Lyrics not available."
+
+func entryHeading(i int, e Entry) string {
+	return fmt.Sprintf("%d. %s - %s", i+1, e.Author, e.Title)
+}
@@ -0,0 +1,114 @@
+package booklet
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+type mapFetcher map[string]string
+
+func (f mapFetcher) FetchLyrics(author, title string) (string, error) {
+	l, ok := f[author+" - "+title]
+	if !ok {
+		return "", lyrics.ErrLyricsNotFound
+	}
+	return l, nil
+}
+
+func testBooklet() *Booklet {
+	songs := []lyrics.SongInfo{
+		{Author: "B", Title: "Second"},
+		{Author: "A", Title: "Missing"},
+		{Author: "A", Title: "First"},
+	}
+	f := mapFetcher{
+		"B - Second": "line *one*\n\n- line two",
+		"A - First":  "<tag> & more",
+	}
+	return Compile("Party", songs, f, nil)
+}
+
+func TestCompileKeepsOrderAndReportsMissing(t *testing.T) {
+	b := testBooklet()
+	titles := []string{"Second", "Missing", "First"}
+	for i, e := range b.Entries {
+		if e.Title != titles[i] {
+			t.Errorf("Entry %d is %s, expected %s", i, e.Title, titles[i])
+		}
+	}
+	missing := b.Missing()
+	if len(missing) != 1 || missing[0].Title != "Missing" {
+		t.Errorf("Unexpected missing entries: %v", missing)
+	}
+	if !strings.Contains(b.Summary(), "2 of 3") {
+		t.Errorf("Summary doesn't count fetched lyrics: %s", b.Summary())
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := testBooklet().Write(&out, Markdown); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Party\n" +
+		"\n## 1. B - Second\n\nline \\*one\\*  \n\n\\- line two  \n" +
+		"\n## 2. A - Missing\n\n*Lyrics not available.*\n" +
+		"\n## 3. A - First\n\n\\<tag\\> & more  \n"
+	if out.String() != expected {
+		t.Errorf("Markdown is:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestHTMLEscapes(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := testBooklet().Write(&out, HTML); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "<p>&lt;tag&gt; &amp; more</p>") {
+		t.Errorf("Lyrics not escaped in:\n%s", out.String())
+	}
+}
+
+func TestEPUBLayout(t *testing.T) {
+	out := bytes.Buffer{}
+	if err := testBooklet().Write(&out, EPUB); err != nil {
+		t.Fatal(err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"mimetype",
+		"META-INF/container.xml",
+		"OEBPS/content.opf",
+		"OEBPS/nav.xhtml",
+		"OEBPS/song-1.xhtml",
+		"OEBPS/song-2.xhtml",
+		"OEBPS/song-3.xhtml",
+	}
+	if len(z.File) != len(expected) {
+		t.Fatalf("Got %d files, expected %d", len(z.File), len(expected))
+	}
+	for i, f := range z.File {
+		if f.Name != expected[i] {
+			t.Errorf("File %d is %s, expected %s", i, f.Name, expected[i])
+		}
+	}
+	if z.File[0].Method != zip.Store {
+		t.Errorf("mimetype should be stored uncompressed")
+	}
+	r, err := z.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mimetype, _ := ioutil.ReadAll(r)
+	if string(mimetype) != epubMimetype {
+		t.Errorf("Wrong mimetype: %s", mimetype)
+	}
+}
@@ -0,0 +1,142 @@
+package booklet
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"html"
+	"io"
+	"time"
+)
+
+// EPUB 3 container layout:
+//
+//	mimetype                  - must be first and stored uncompressed
+//	META-INF/container.xml    - points to the package document
+//	OEBPS/content.opf         - metadata, manifest and spine
+//	OEBPS/nav.xhtml           - table of contents
+//	OEBPS/song-N.xhtml        - one chapter per entry
+const (
+	epubMimetype  = "application/epub+zip"
+	epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+	xhtmlHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+<meta charset="utf-8"/>
+<title>%s</title>
+</head>
+<body>
+`
+	xhtmlFooter = "</body>\n</html>\n"
+)
+
+type epubFile struct {
+	name    string
+	content []byte
+}
+
+func (b *Booklet) writeEPUB(out io.Writer) error {
+	z := zip.NewWriter(out)
+	mimetype, err := z.CreateHeader(&zip.FileHeader{
+		Name:   "mimetype",
+		Method: zip.Store,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(mimetype, epubMimetype); err != nil {
+		return err
+	}
+	id, err := uuid()
+	if err != nil {
+		return err
+	}
+	files := []epubFile{
+		{"META-INF/container.xml", []byte(epubContainer)},
+		{"OEBPS/content.opf", b.epubPackage(id)},
+		{"OEBPS/nav.xhtml", b.epubNav()},
+	}
+	for i, e := range b.Entries {
+		files = append(files, epubFile{"OEBPS/" + chapterName(i), epubChapter(i, e)})
+	}
+	for _, f := range files {
+		w, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(f.content); err != nil {
+			return err
+		}
+	}
+	return z.Close()
+}
+
+func chapterName(i int) string {
+	return fmt.Sprintf("song-%d.xhtml", i+1)
+}
+
+func (b *Booklet) epubPackage(id string) []byte {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="book-id">urn:uuid:%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>und</dc:language>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+<item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+`, id, html.EscapeString(b.Title), time.Now().UTC().Format("2006-01-02T15:04:05Z"))
+	for i := range b.Entries {
+		fmt.Fprintf(&buf, "<item id=\"song-%d\" href=\"%s\" media-type=\"application/xhtml+xml\"/>\n", i+1, chapterName(i))
+	}
+	buf.WriteString("</manifest>\n<spine>\n<itemref idref=\"nav\"/>\n")
+	for i := range b.Entries {
+		fmt.Fprintf(&buf, "<itemref idref=\"song-%d\"/>\n", i+1)
+	}
+	buf.WriteString("</spine>\n</package>\n")
+	return buf.Bytes()
+}
+
+func (b *Booklet) epubNav() []byte {
+	buf := bytes.Buffer{}
+	title := html.EscapeString(b.Title)
+	fmt.Fprintf(&buf, xhtmlHeader, title)
+	fmt.Fprintf(&buf, "<nav epub:type=\"toc\" id=\"toc\">\n<h1>%s</h1>\n<ol>\n", title)
+	for i, e := range b.Entries {
+		fmt.Fprintf(&buf, "<li><a href=\"%s\">%s</a></li>\n",
+			chapterName(i), html.EscapeString(entryHeading(i, e)))
+	}
+	buf.WriteString("</ol>\n</nav>\n")
+	buf.WriteString(xhtmlFooter)
+	return buf.Bytes()
+}
+
+func epubChapter(i int, e Entry) []byte {
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, xhtmlHeader, html.EscapeString(entryHeading(i, e)))
+	writeHTMLEntry(&buf, i, e)
+	buf.WriteString(xhtmlFooter)
+	return buf.Bytes()
+}
+
+// uuid returns random version 4 uuid
+// used as the EPUB unique identifier.
+func uuid() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,98 @@
+package booklet
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+func (b *Booklet) writeMarkdown(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	fmt.Fprintf(w, "# %s\n", markdownEscape(b.Title))
+	for i, e := range b.Entries {
+		fmt.Fprintf(w, "\n## %s\n\n", markdownEscape(entryHeading(i, e)))
+		if e.Missing() {
+			fmt.Fprintf(w, "*%s*\n", notAvailable)
+			continue
+		}
+		// Two trailing spaces make markdown keep
+		// the line breaks of the verses.
+		for _, line := range strings.Split(strings.TrimSpace(e.Lyrics), "\n") {
+			line = strings.TrimRight(line, " \t\r")
+			if line == "" {
+				fmt.Fprintln(w)
+				continue
+			}
+			line = markdownEscape(line)
+			// Lines starting with a dash would become list items.
+			if strings.HasPrefix(line, "-") || strings.HasPrefix(line, "+") {
+				line = `\` + line
+			}
+			fmt.Fprintf(w, "%s  \n", line)
+		}
+	}
+	return w.Flush()
+}
+
+var markdownReplacer = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`,
+)
+
+func markdownEscape(s string) string {
+	return markdownReplacer.Replace(s)
+}
+
+func (b *Booklet) writeHTML(out io.Writer) error {
+	w := bufio.NewWriter(out)
+	title := html.EscapeString(b.Title)
+	fmt.Fprintf(w, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n</head>\n<body>\n", title)
+	fmt.Fprintf(w, "<h1>%s</h1>\n", title)
+	for i, e := range b.Entries {
+		writeHTMLEntry(w, i, e)
+	}
+	fmt.Fprint(w, "</body>\n</html>\n")
+	return w.Flush()
+}
+
+// writeHTMLEntry writes the entry as a html section.
+// Output is valid XHTML as well so it is
+// reused for the EPUB chapters.
+func writeHTMLEntry(w io.Writer, i int, e Entry) {
+	fmt.Fprintf(w, "<section id=\"song-%d\">\n<h2>%s</h2>\n", i+1, html.EscapeString(entryHeading(i, e)))
+	if e.Missing() {
+		fmt.Fprintf(w, "<p><em>%s</em></p>\n</section>\n", notAvailable)
+		return
+	}
+	for _, verse := range verses(e.Lyrics) {
+		lines := make([]string, len(verse))
+		for j, line := range verse {
+			lines[j] = html.EscapeString(line)
+		}
+		fmt.Fprintf(w, "<p>%s</p>\n", strings.Join(lines, "<br/>\n"))
+	}
+	fmt.Fprint(w, "</section>\n")
+}
+
+// verses splits lyrics into blank line separated verses.
+func verses(lyrics string) [][]string {
+	all := [][]string{}
+	curr := []string{}
+	for _, line := range strings.Split(lyrics, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			if len(curr) > 0 {
+				all = append(all, curr)
+				curr = []string{}
+			}
+			continue
+		}
+		curr = append(curr, line)
+	}
+	if len(curr) > 0 {
+		all = append(all, curr)
+	}
+	return all
+}
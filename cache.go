@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gala377/Lyricer/config"
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+// cacheCommand inspects and purges the on-disk lyrics cache.
+func cacheCommand(conf *config.LyricerConfig, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("expected list or purge")
+	}
+	if conf.Lyrics.Cache.Dir == "" {
+		return fmt.Errorf("cache dir is not configured")
+	}
+	cache := newCache(conf, nil)
+	switch args[0] {
+	case "list":
+		return listCache(cache, args[1:])
+	case "purge":
+		return purgeCache(cache, args[1:])
+	}
+	return fmt.Errorf("unknown cache subcommand %s", args[0])
+}
+
+func listCache(cache *lyrics.Cache, args []string) error {
+	flags := flag.NewFlagSet("cache list", flag.ExitOnError)
+	lang := flags.String("lang", "", "list only lyrics in the language with the given ISO 639-1 code")
+	flags.Parse(args)
+
+	all, err := cache.Entries()
+	if err != nil {
+		return err
+	}
+	entries := []lyrics.CacheEntry{}
+	for _, e := range all {
+		if *lang == "" || e.Language == *lang {
+			entries = append(entries, e)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StoredAt.After(entries[j].StoredAt)
+	})
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ARTIST\tTITLE\tKIND\tLANG\tPROVIDER\tSTATE\tEXPIRES")
+	for _, e := range entries {
+		kind := "plain"
+		if e.Synced {
+			kind = "synced"
+		}
+		state := "found"
+		if e.NotFound {
+			state = "not found"
+		}
+		if e.Instrumental {
+			state = "instrumental"
+		}
+		if e.Expired(now) {
+			state += " (expired)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.Artist, e.Title, kind, e.Language, e.Provider, state, e.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(w, "%d entries\n", len(entries))
+	return w.Flush()
+}
+
+func purgeCache(cache *lyrics.Cache, args []string) error {
+	flags := flag.NewFlagSet("cache purge", flag.ExitOnError)
+	expired := flags.Bool("expired", false, "purge only expired entries")
+	notFound := flags.Bool("notfound", false, "purge only cached not found results")
+	match := flags.String("match", "", "purge only entries which artist or title contains the text")
+	lang := flags.String("lang", "", "purge only lyrics in the language with the given ISO 639-1 code")
+	flags.Parse(args)
+
+	now := time.Now()
+	text := strings.ToLower(*match)
+	removed, err := cache.Purge(func(e lyrics.CacheEntry) bool {
+		if *expired && !e.Expired(now) {
+			return false
+		}
+		if *notFound && !e.NotFound {
+			return false
+		}
+		if *lang != "" && e.Language != *lang {
+			return false
+		}
+		if text != "" &&
+			!strings.Contains(strings.ToLower(e.Artist), text) &&
+			!strings.Contains(strings.ToLower(e.Title), text) {
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Purged %d entries\n", removed)
+	return nil
+}
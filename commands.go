@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/gala377/Lyricer/config"
+)
+
+// command is a Lyricer subcommand
+// run as "lyricer <name> [args...]".
+type command struct {
+	usage string
+	run   func(conf *config.LyricerConfig, args []string) error
+}
+
+var commands = map[string]command{
+	"archive": {
+		usage: "archive list [-lang code] [-missing]",
+		run:   archiveCommand,
+	},
+	"cache": {
+		usage: "cache list [-lang code] | cache purge [-expired] [-notfound] [-match text] [-lang code]",
+		run:   cacheCommand,
+	},
+	"edit": {
+		usage: "edit [-remove] [track id]",
+		run:   editCommand,
+	},
+	"export": {
+		usage: "export [-format md|html|epub] [-o file] <playlist or album uri>",
+		run:   exportCommand,
+	},
+	"record": {
+		usage: "record [-local]",
+		run:   recordCommand,
+	},
+	"search": {
+		usage: "search [-market CC] <query>",
+		run:   searchCommand,
+	},
+	"sync": {
+		usage: "sync [-j workers] [-full]",
+		run:   syncCommand,
+	},
+}
+
+// runCommand runs the subcommand with the given name
+// and exits the app on failure.
+func runCommand(conf *config.LyricerConfig, name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage()
+		os.Exit(2)
+	}
+	if err := cmd.run(conf, args); err != nil {
+		log.Fatalf("%s: %s", name, err)
+	}
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "Usage:\n\tlyricer\t\tshow lyrics of the currently played song")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\tlyricer %s\n", commands[name].usage)
+	}
+}
+
+// writeFile creates the file under the given path
+// and writes to it using the write function.
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
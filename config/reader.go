@@ -23,12 +23,143 @@ type OAuthData struct {
 	Scopes      []string
 }
 
+// CacheConfig configures the lyrics cache.
+// Durations are in the Go time.ParseDuration format,
+// for example "720h". Zero values mean the defaults.
+type CacheConfig struct {
+	// Dir is the directory cached lyrics are stored in.
+	// If it's empty lyrics are cached only in memory.
+	Dir         string
+	MaxEntries  int
+	TTL         string
+	NegativeTTL string
+}
+
+// CleanupConfig configures the transforms
+// cleaning up the fetched lyrics.
+type CleanupConfig struct {
+	// Transforms are the names of the transforms, "entities",
+	// "junk", "headers", "profanity", "rules" or "whitespace",
+	// in the order they are applied. Empty means the
+	// "entities", "junk", "rules" and "whitespace".
+	Transforms []string
+	// ProfanityWords are the words the "profanity"
+	// transform masks. Empty means the defaults.
+	ProfanityWords []string
+	// Rules are the regular expression replacements
+	// the "rules" transform applies.
+	Rules []RegexRuleConfig
+}
+
+// RegexRuleConfig replaces the matches of the Pattern,
+// in the Go regexp syntax, with the Replacement.
+type RegexRuleConfig struct {
+	Pattern     string
+	Replacement string
+}
+
+// ProviderConfig configures the lyrics
+// provider speaking some web api.
+type ProviderConfig struct {
+	// URL is the base url of the api,
+	// empty means the public instance.
+	URL string
+	// Token authenticates the requests
+	// if the api requires it.
+	Token string
+}
+
+// LyricsConfig configures the lyrics providers.
+type LyricsConfig struct {
+	// Providers are the names of the lyrics
+	// providers in the order they are asked.
+	Providers []string
+	// Race makes all of the providers to be asked
+	// at once, the first found lyrics win.
+	Race bool
+	// MatchThreshold is the confidence, from 0 to 1,
+	// a search result needs to be accepted.
+	// Zero means lyrics.DefaultMatchThreshold.
+	MatchThreshold float64
+	// Instrumentalness is the spotify audio features
+	// instrumentalness, from 0 to 1, at which the tracks
+	// without lyrics found are taken as instrumental.
+	// Zero means lyrics.DefaultInstrumentalness,
+	// negative disables the check.
+	Instrumentalness float64
+	// LocalDirs are the directories of .lrc and .txt
+	// files the "local" provider looks the lyrics up in.
+	LocalDirs []string
+	// LocalTemplates are the file name templates like
+	// "{artist} - {title}.lrc" the "local" provider
+	// matches files with. Empty means the defaults.
+	LocalTemplates []string
+	// Versions is how many versions of the lyrics, from
+	// the providers and the lrclib search results, are
+	// fetched to choose from. Zero or one means only
+	// the first found lyrics.
+	Versions int
+	// OverridesDir is the directory the lyrics saved by
+	// the user for the tracks, with the "edit" command,
+	// and the tracks sync offsets are stored in.
+	// Empty disables the overrides.
+	OverridesDir string
+	// LRCLib configures the "lrclib" provider.
+	LRCLib ProviderConfig
+	// Genius configures the "genius" provider.
+	Genius  ProviderConfig
+	Cache   CacheConfig
+	Cleanup CleanupConfig
+}
+
+// TranslationConfig configures translating the lyrics.
+type TranslationConfig struct {
+	// Target is the ISO 639-1 code of the language
+	// the lyrics are translated to, empty disables
+	// the translations.
+	Target string
+	// Translators are the names of the translators,
+	// "tekstowo" or "libretranslate", in the order
+	// they are asked.
+	Translators []string
+	// LibreTranslate configures the "libretranslate"
+	// translator, the Token is the api key.
+	LibreTranslate ProviderConfig
+	// CacheDir is the directory translations are
+	// stored in. If it's empty they are cached
+	// only in memory.
+	CacheDir string
+}
+
+// RomanizationConfig configures romanizing
+// the lyrics not in the Latin script.
+type RomanizationConfig struct {
+	// Enabled romanizes the Cyrillic, Greek
+	// and Korean lyrics.
+	Enabled bool
+	// JapaneseCommand is the program with its arguments,
+	// like kakasi, romanizing the Japanese text read
+	// from its standard input. Empty leaves the
+	// Japanese lyrics as they are.
+	JapaneseCommand []string
+}
+
 // LyricerConfig is the data needed
 // for the Lyricer app to successfuly
 // access services providers (for now only spotify)
 // web api.
 type LyricerConfig struct {
 	Spotify OAuthData
+	// Market is the ISO 3166-1 alpha-2 country code
+	// or "from_token" sent with the spotify track
+	// requests. Empty means no market.
+	Market string
+	// ArchiveDir is the directory the synced
+	// library lyrics are stored in.
+	ArchiveDir   string
+	Lyrics       LyricsConfig
+	Translation  TranslationConfig
+	Romanization RomanizationConfig
 }
 
 // Read opens and reads the configuration
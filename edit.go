@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gala377/Lyricer/config"
+	"github.com/gala377/Lyricer/lyrics"
+	"github.com/gala377/Lyricer/spotify"
+)
+
+// defaultEditor is run if the $EDITOR is not set.
+const defaultEditor = "vi"
+
+// editCommand opens the lyrics of the currently played song,
+// or of the track with the given id, in the $EDITOR and saves
+// the edited ones as the lyrics override of the track.
+func editCommand(conf *config.LyricerConfig, args []string) error {
+	flags := flag.NewFlagSet("edit", flag.ExitOnError)
+	remove := flags.Bool("remove", false, "remove the saved lyrics, so they are fetched again")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		return fmt.Errorf("expected at most one track id")
+	}
+	if conf.Lyrics.OverridesDir == "" {
+		return fmt.Errorf("overrides dir is not configured")
+	}
+
+	spot, err := authorize(conf)
+	if err != nil {
+		return err
+	}
+	track, err := editedTrack(spot, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	overrides := lyrics.NewOverrides(nil, conf.Lyrics.OverridesDir)
+	if *remove {
+		if err = overrides.Remove(track.ID); err != nil {
+			return err
+		}
+		fmt.Printf("Removed saved lyrics of %s - %s\n", track.Artist(), track.Title)
+		return nil
+	}
+
+	song := songInfo(spot, track)
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	err = song.FetchContext(ctx, lyrics.Adapt(newFetcher(conf, spot)))
+	if kind := lyrics.KindOf(err); err != nil && kind != lyrics.NotFound && kind != lyrics.Instrumental {
+		return fmt.Errorf("could not fetch lyrics for %s - %s: %s", song.Author, song.Title, err)
+	}
+	text, ext := song.Lyrics, ".txt"
+	if song.Synced != nil {
+		text, ext = song.Synced.LRC(), ".lrc"
+	}
+	edited, err := editText(text, ext)
+	if err != nil {
+		return err
+	}
+	if edited == text {
+		fmt.Println("Lyrics not changed")
+		return nil
+	}
+	if strings.TrimSpace(edited) == "" {
+		fmt.Println("Lyrics left empty, removing the saved ones")
+		return overrides.Remove(track.ID)
+	}
+	l, err := editedLyrics(edited, ext == ".lrc")
+	if err != nil {
+		return err
+	}
+	if err = overrides.Save(track.ID, l); err != nil {
+		return err
+	}
+	fmt.Printf("Saved lyrics of %s - %s\n", song.Author, song.Title)
+	return nil
+}
+
+// editedLyrics returns the lyrics out of the edited text.
+// Only the synced ones are parsed as LRC, plain lines like
+// "[Chorus: Freddie Mercury]" or "#1" would be taken
+// for the tags and comments and dropped.
+func editedLyrics(text string, synced bool) (*lyrics.Lyrics, error) {
+	if !synced {
+		return lyrics.PlainLyrics(text), nil
+	}
+	return lyrics.ParseLRC(strings.NewReader(text))
+}
+
+// editedTrack returns the track with the id
+// or the currently played one if the id is empty.
+func editedTrack(s *spotify.Spotify, id string) (spotify.Track, error) {
+	if id != "" {
+		return s.Track(id)
+	}
+	played, err := s.CurrentlyPlayedSong()
+	if err != nil {
+		return spotify.Track{}, fmt.Errorf("couldn't retrieve currently played song %s", err)
+	}
+	if played.Track.ID == "" {
+		return spotify.Track{}, fmt.Errorf("nothing is played right now")
+	}
+	return played.Track, nil
+}
+
+// editText lets the user edit the text in the $EDITOR,
+// in a temporary file with the given extension,
+// and returns the edited text.
+func editText(text, ext string) (string, error) {
+	file, err := ioutil.TempFile("", "lyricer-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(text)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{defaultEditor}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %s", err)
+	}
+	data, err := ioutil.ReadFile(file.Name())
+	return string(data), err
+}
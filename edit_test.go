@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+func TestEditedLyrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	overrides := lyrics.NewOverrides(nil, dir)
+
+	cases := []struct {
+		name     string
+		text     string
+		synced   bool
+		expected string
+	}{
+		{
+			"plain keeps tag and comment shaped lines",
+			"[Chorus: Freddie Mercury]\nUnder pressure\n#1 on the list\nend\n",
+			false,
+			"[Chorus: Freddie Mercury]\nUnder pressure\n#1 on the list\nend",
+		},
+		{
+			"synced parsed as LRC",
+			"[ar:Queen]\n[00:01.00]Under pressure\n[00:02.00]end\n",
+			true,
+			"Under pressure\nend",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, err := editedLyrics(c.text, c.synced)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = overrides.Save("id", l); err != nil {
+				t.Fatal(err)
+			}
+			saved, err := overrides.Lyrics("id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if saved.Text() != c.expected || saved.Synced() != c.synced {
+				t.Errorf("got %q synced %v expected %q", saved.Text(), saved.Synced(), c.expected)
+			}
+			if c.synced && saved.Lines[1].Time != 2*time.Second {
+				t.Errorf("timing lost, got %+v", saved.Lines)
+			}
+		})
+	}
+}
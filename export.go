@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gala377/Lyricer/booklet"
+	"github.com/gala377/Lyricer/config"
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+// exportCommand writes lyrics of every track of the
+// playlist or album into a single booklet document.
+func exportCommand(conf *config.LyricerConfig, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	formatName := flags.String("format", "", "output format: md, html or epub (default from -o extension or md)")
+	outPath := flags.String("o", "", "output file (default stdout)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf("expected exactly one playlist or album uri")
+	}
+	if *formatName == "" {
+		*formatName = string(booklet.Markdown)
+		if ext := filepath.Ext(*outPath); ext != "" {
+			*formatName = ext
+		}
+	}
+	format, err := booklet.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	if format == booklet.EPUB && *outPath == "" {
+		return fmt.Errorf("epub output needs a file, use -o")
+	}
+
+	spotify, err := authorize(conf)
+	if err != nil {
+		return err
+	}
+	collection, err := spotify.Collection(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	songs := make([]lyrics.SongInfo, len(collection.Tracks))
+	for i, t := range collection.Tracks {
+		songs[i] = songInfo(spotify, t)
+	}
+	b := booklet.Compile(collection.Name, songs, newFetcher(conf, spotify), func(done int, e booklet.Entry) {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s - %s\n", done, len(songs), e.Author, e.Title)
+	})
+
+	if *outPath == "" {
+		err = b.Write(os.Stdout, format)
+	} else {
+		err = writeFile(*outPath, func(w io.Writer) error {
+			return b.Write(w, format)
+		})
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stderr, b.Summary())
+	return nil
+}
@@ -0,0 +1,67 @@
+package karaoke
+
+import (
+	"time"
+)
+
+// SeekThreshold is how far the reported progress can drift
+// from the interpolated one before it's treated as a seek.
+const SeekThreshold = 1500 * time.Millisecond
+
+// Clock interpolates the song progress locally
+// between the polls of the spotify player state.
+type Clock struct {
+	progress time.Duration
+	at       time.Time
+	playing  bool
+	// now is time.Now, replaced in tests.
+	now func() time.Time
+}
+
+// NewClock returns clock stopped at the beginning of the song.
+func NewClock() *Clock {
+	return &Clock{now: time.Now}
+}
+
+// Reset sets the clock to the progress reported
+// at the given moment.
+func (c *Clock) Reset(progress time.Duration, at time.Time, playing bool) {
+	c.progress = progress
+	c.at = at
+	c.playing = playing
+}
+
+// Sync corrects the clock with the progress reported
+// at the given moment. Small drifts caused by the request
+// latency are smoothed, drifts bigger than SeekThreshold
+// are treated as a seek and the clock jumps to the reported
+// progress. Returns whether the seek was detected.
+func (c *Clock) Sync(progress time.Duration, at time.Time, playing bool) bool {
+	expected := c.progressAt(at)
+	drift := progress - expected
+	if drift < 0 {
+		drift = -drift
+	}
+	seeked := drift > SeekThreshold
+	if seeked || !playing || !c.playing {
+		c.Reset(progress, at, playing)
+		return seeked
+	}
+	// Meet the reported progress halfway
+	// so the lyrics don't jitter.
+	c.Reset(expected+(progress-expected)/2, at, playing)
+	return false
+}
+
+// Now returns the interpolated progress.
+func (c *Clock) Now() time.Duration {
+	return c.progressAt(c.now())
+}
+
+// progressAt returns the interpolated progress at the given moment.
+func (c *Clock) progressAt(t time.Time) time.Duration {
+	if !c.playing {
+		return c.progress
+	}
+	return c.progress + t.Sub(c.at)
+}
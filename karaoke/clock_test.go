@@ -0,0 +1,35 @@
+package karaoke
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockInterpolatesAndDetectsSeek(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	c := &Clock{now: func() time.Time { return now }}
+	c.Reset(10*time.Second, start, true)
+
+	now = start.Add(2 * time.Second)
+	if c.Now() != 12*time.Second {
+		t.Errorf("Interpolated progress is %s expected 12s", c.Now())
+	}
+	// Small drift is smoothed.
+	if c.Sync(12400*time.Millisecond, now, true) {
+		t.Errorf("Latency drift treated as a seek")
+	}
+	if c.Now() != 12200*time.Millisecond {
+		t.Errorf("Smoothed progress is %s expected 12.2s", c.Now())
+	}
+	// Big drift is a seek.
+	if !c.Sync(60*time.Second, now, true) || c.Now() != 60*time.Second {
+		t.Errorf("Seek not detected, progress is %s", c.Now())
+	}
+	// Paused clock doesn't move.
+	c.Sync(60*time.Second, now, false)
+	now = now.Add(time.Minute)
+	if c.Now() != 60*time.Second {
+		t.Errorf("Paused clock moved to %s", c.Now())
+	}
+}
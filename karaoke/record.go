@@ -0,0 +1,100 @@
+package karaoke
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+// Recorder makes the synced lyrics out of the plain ones
+// as the user marks the lines when they start to be sung.
+type Recorder struct {
+	texts []string
+	lines []lyrics.Line
+	// next is the index of the text to mark next.
+	next int
+}
+
+// NewRecorder returns Recorder timing the lines of
+// the lyrics. Blank lines are skipped, breaks between
+// the verses are marked with Break.
+func NewRecorder(l *lyrics.Lyrics) *Recorder {
+	r := &Recorder{}
+	for _, line := range l.Lines {
+		if text := strings.TrimSpace(line.Text); text != "" {
+			r.texts = append(r.texts, text)
+		}
+	}
+	return r
+}
+
+// Next returns the line to mark next and
+// whether there is any left.
+func (r *Recorder) Next() (string, bool) {
+	if r.Done() {
+		return "", false
+	}
+	return r.texts[r.next], true
+}
+
+// Done reports whether all of the lines are marked.
+func (r *Recorder) Done() bool {
+	return r.next >= len(r.texts)
+}
+
+// Marked returns the number of the lines marked
+// and the number of all of them.
+func (r *Recorder) Marked() (int, int) {
+	return r.next, len(r.texts)
+}
+
+// Mark times the next line at the progress. Lines can't
+// go back in time, so the progress before the previous
+// line is moved to it. Returns the timed line.
+func (r *Recorder) Mark(progress time.Duration) lyrics.Line {
+	text, ok := r.Next()
+	if !ok {
+		return lyrics.Line{Time: lyrics.NoTime}
+	}
+	r.next++
+	return r.add(progress, text)
+}
+
+// Break marks the end of the previous line with the
+// blank line at the progress, like before the solo.
+func (r *Recorder) Break(progress time.Duration) lyrics.Line {
+	return r.add(progress, "")
+}
+
+func (r *Recorder) add(progress time.Duration, text string) lyrics.Line {
+	if n := len(r.lines); n > 0 && progress < r.lines[n-1].Time {
+		progress = r.lines[n-1].Time
+	}
+	if progress < 0 {
+		progress = 0
+	}
+	line := lyrics.Line{Time: progress, Text: text}
+	r.lines = append(r.lines, line)
+	return line
+}
+
+// Undo removes the last mark or break.
+func (r *Recorder) Undo() {
+	n := len(r.lines)
+	if n == 0 {
+		return
+	}
+	if r.lines[n-1].Text != "" {
+		r.next--
+	}
+	r.lines = r.lines[:n-1]
+}
+
+// Lyrics returns the synced lyrics of the lines marked so far.
+func (r *Recorder) Lyrics() *lyrics.Lyrics {
+	return &lyrics.Lyrics{
+		Tags:  map[string]string{},
+		Lines: append([]lyrics.Line(nil), r.lines...),
+	}
+}
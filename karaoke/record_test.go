@@ -0,0 +1,47 @@
+package karaoke
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder(lyrics.PlainLyrics("one\n\n  two  \nthree"))
+	r.Mark(time.Second)
+	r.Break(3 * time.Second)
+	r.Mark(2 * time.Second)
+	r.Mark(5 * time.Second)
+	r.Undo()
+	if marked, all := r.Marked(); marked != 2 || all != 3 || r.Done() {
+		t.Errorf("marked %d of %d lines", marked, all)
+	}
+	if next, _ := r.Next(); next != "three" {
+		t.Errorf("got next line %q", next)
+	}
+	r.Mark(6 * time.Second)
+	if !r.Done() {
+		t.Errorf("all lines marked but not done")
+	}
+
+	expected := []lyrics.Line{
+		{Time: time.Second, Text: "one"},
+		{Time: 3 * time.Second, Text: ""},
+		// Marked too early, moved to the break.
+		{Time: 3 * time.Second, Text: "two"},
+		{Time: 6 * time.Second, Text: "three"},
+	}
+	got := r.Lyrics()
+	if len(got.Lines) != len(expected) {
+		t.Fatalf("got lines %+v", got.Lines)
+	}
+	for i, line := range got.Lines {
+		if line.Time != expected[i].Time || line.Text != expected[i].Text {
+			t.Errorf("line %d is %+v expected %+v", i, line, expected[i])
+		}
+	}
+	if !got.Synced() {
+		t.Errorf("recorded lyrics not synced")
+	}
+}
@@ -0,0 +1,284 @@
+// Package karaoke implements terminal view scrolling
+// through the synced lyrics and highlighting the
+// line currently being sung.
+package karaoke
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+// ANSI escape sequences used by the view.
+const (
+	clearScreen = "\033[H\033[2J"
+	highlight   = "\033[1;7m"
+	dim         = "\033[2m"
+	reset       = "\033[0m"
+)
+
+// OffsetStep is how much a single offset
+// adjustment moves the lyrics.
+const OffsetStep = 250 * time.Millisecond
+
+// MaxTaps is the number of the last taps
+// the tapped offset is averaged over.
+const MaxTaps = 5
+
+// View renders the synced lyrics of the played song
+// to the terminal. It's safe for concurrent use so the
+// song can be rendered while the user adjusts the offset.
+type View struct {
+	// Context is the number of lines shown
+	// before and after the current one.
+	Context int
+
+	mu     sync.Mutex
+	out    io.Writer
+	title  string
+	lyrics *lyrics.Lyrics
+	clock  *Clock
+	offset time.Duration
+	// taps are the offsets tapped for the song.
+	taps []time.Duration
+	// translated shows the line translations
+	// next to the lines.
+	translated bool
+	// romanized shows the romanized lines
+	// under the lines.
+	romanized bool
+	// drawn is the line highlighted in the last frame,
+	// so frames are redrawn only if it changed.
+	drawn int
+	dirty bool
+}
+
+// NewView returns View writing to out.
+func NewView(out io.Writer) *View {
+	return &View{
+		Context: 4,
+		out:     out,
+		clock:   NewClock(),
+		dirty:   true,
+	}
+}
+
+// SetSong starts showing lyrics of the new song
+// at the given progress.
+func (v *View) SetSong(title string, l *lyrics.Lyrics, progress time.Duration, at time.Time, playing bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.title = title
+	v.lyrics = l
+	v.taps = nil
+	v.clock.Reset(progress, at, playing)
+	v.dirty = true
+}
+
+// Sync corrects the local clock with the progress
+// reported by the player. Returns whether a seek
+// was detected.
+func (v *View) Sync(progress time.Duration, at time.Time, playing bool) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.clock.Sync(progress, at, playing)
+}
+
+// AdjustOffset moves the lyrics by d on top of the
+// lyrics own offset. Positive d shows the lines sooner.
+// Returns the resulting user offset.
+func (v *View) AdjustOffset(d time.Duration) time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.offset += d
+	v.dirty = true
+	return v.offset
+}
+
+// SetOffset sets the user offset, like the
+// one saved for the song.
+func (v *View) SetOffset(d time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.offset = d
+	v.dirty = true
+}
+
+// Offset returns the user offset.
+func (v *View) Offset() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.offset
+}
+
+// Tap calibrates the offset to the moment the user tapped
+// as a line started to be sung. The tapped line is the one
+// starting closest to the moment with the current offset,
+// so the offset can be corrected in a few taps. The offset
+// is averaged over the last MaxTaps taps of the song.
+// Returns the resulting user offset and whether there
+// was a line to tap.
+func (v *View) Tap() (time.Duration, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.lyrics == nil {
+		return v.offset, false
+	}
+	now := v.clock.Now()
+	line := v.lyrics.NearestLine(now + v.offset)
+	if line < 0 {
+		return v.offset, false
+	}
+	v.taps = append(v.taps, v.lyrics.Lines[line].Time-v.lyrics.Offset-now)
+	if len(v.taps) > MaxTaps {
+		v.taps = v.taps[1:]
+	}
+	sum := time.Duration(0)
+	for _, tap := range v.taps {
+		sum += tap
+	}
+	v.offset = sum / time.Duration(len(v.taps))
+	v.dirty = true
+	return v.offset, true
+}
+
+// ToggleTranslation switches showing the translations
+// of the lines side by side with them, if the lyrics
+// are translated. Returns whether they are shown now.
+func (v *View) ToggleTranslation() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.translated = !v.translated
+	v.dirty = true
+	return v.translated
+}
+
+// ToggleRomanization switches showing the romanized lines
+// under the lines, if the lyrics are romanized.
+// Returns whether they are shown now.
+func (v *View) ToggleRomanization() bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.romanized = !v.romanized
+	v.dirty = true
+	return v.romanized
+}
+
+// Render draws the frame if the current line changed
+// since the last one.
+func (v *View) Render() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.lyrics == nil {
+		return nil
+	}
+	current := v.lyrics.LineAt(v.clock.Now() + v.offset)
+	if current == v.drawn && !v.dirty {
+		return nil
+	}
+	v.drawn = current
+	v.dirty = false
+	return v.draw(current)
+}
+
+func (v *View) draw(current int) error {
+	w := bufio.NewWriter(v.out)
+	fmt.Fprint(w, clearScreen)
+	fmt.Fprintf(w, "%s%s%s\n", dim, v.title, reset)
+	if v.offset != 0 {
+		fmt.Fprintf(w, "%soffset %+.2fs%s\n", dim, v.offset.Seconds(), reset)
+	}
+	fmt.Fprintln(w)
+
+	// Keep the current line in the middle of the window.
+	from := current - v.Context
+	to := current + v.Context
+	if current < 0 {
+		from, to = 0, 2*v.Context
+	}
+	width := -1
+	if v.translated && v.lyrics.Translated() {
+		width = columnWidth(window(v.lyrics.Lines, from, to))
+	}
+	for i := from; i <= to; i++ {
+		if i < 0 || i >= len(v.lyrics.Lines) {
+			fmt.Fprintln(w)
+			continue
+		}
+		line := v.lyrics.Lines[i]
+		text := line.Text
+		if width >= 0 {
+			text = sideBySide(line, width)
+		}
+		switch {
+		case i == current:
+			fmt.Fprintf(w, "%s %s %s\n", highlight, text, reset)
+		case i < current:
+			fmt.Fprintf(w, "%s %s%s\n", dim, text, reset)
+		default:
+			fmt.Fprintf(w, " %s\n", text)
+		}
+		if v.romanized && line.Romanized != "" {
+			fmt.Fprintf(w, "%s %s%s\n", dim, line.Romanized, reset)
+		}
+	}
+	return w.Flush()
+}
+
+// gutter separates the lines from their translations.
+const gutter = "   "
+
+// Text returns the lyrics text with the line translations
+// in the second column and the romanized lines under
+// the original ones.
+func Text(l *lyrics.Lyrics) string {
+	width := columnWidth(l.Lines)
+	lines := []string{}
+	for _, line := range l.Lines {
+		lines = append(lines, strings.TrimRight(sideBySide(line, width), " "))
+		if line.Romanized != "" {
+			lines = append(lines, line.Romanized)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sideBySide(line lyrics.Line, width int) string {
+	if line.Translation == "" {
+		return line.Text
+	}
+	pad := width - utf8.RuneCountInString(line.Text)
+	return line.Text + strings.Repeat(" ", pad) + gutter + line.Translation
+}
+
+// window returns the lines from and to the
+// given ones, clamped to the lyrics.
+func window(lines []lyrics.Line, from, to int) []lyrics.Line {
+	if from < 0 {
+		from = 0
+	}
+	if to >= len(lines) {
+		to = len(lines) - 1
+	}
+	if from > to {
+		return nil
+	}
+	return lines[from : to+1]
+}
+
+// columnWidth is the width of the longest line.
+func columnWidth(lines []lyrics.Line) int {
+	width := 0
+	for _, line := range lines {
+		if n := utf8.RuneCountInString(line.Text); n > width {
+			width = n
+		}
+	}
+	return width
+}
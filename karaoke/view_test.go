@@ -0,0 +1,54 @@
+package karaoke
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+func TestText(t *testing.T) {
+	l := &lyrics.Lyrics{Lines: []lyrics.Line{
+		{Text: "Is this the real life?", Translation: "Czy to prawdziwe życie?"},
+		{Text: ""},
+		{Text: "Żółw", Translation: "Turtle"},
+		{Text: "untranslated"},
+		{Text: "Привет", Romanized: "Privet"},
+	}}
+	expected := "Is this the real life?   Czy to prawdziwe życie?\n" +
+		"\n" +
+		"Żółw                     Turtle\n" +
+		"untranslated\n" +
+		"Привет\n" +
+		"Privet"
+	if got := Text(l); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+}
+
+func TestTap(t *testing.T) {
+	l, _ := lyrics.ParseLRC(strings.NewReader("[00:10.00]one\n[00:15.00]two\n[00:20.00]three\n"))
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now := start
+	v := NewView(ioutil.Discard)
+	v.clock.now = func() time.Time { return now }
+	v.SetSong("song", l, 9*time.Second, start, true)
+
+	// Lyrics are two seconds late, "one" is sung at 8s.
+	now = start.Add(-time.Second)
+	if offset, ok := v.Tap(); !ok || offset != 2*time.Second {
+		t.Errorf("got offset %s, %v expected 2s", offset, ok)
+	}
+	// Tapping a bit too late for "two" at 13s.
+	now = start.Add(4500 * time.Millisecond)
+	if offset, _ := v.Tap(); offset != 1750*time.Millisecond {
+		t.Errorf("got offset %s expected the average 1.75s", offset)
+	}
+	v.SetSong("other", l, 0, start, true)
+	now = start.Add(10 * time.Second)
+	if offset, _ := v.Tap(); offset != 0 {
+		t.Errorf("taps of the previous song kept, got offset %s", offset)
+	}
+}
@@ -0,0 +1,501 @@
+package lyrics
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default Cache limits.
+const (
+	DefaultCacheEntries = 500
+	DefaultCacheTTL     = 30 * 24 * time.Hour
+	DefaultNegativeTTL  = 24 * time.Hour
+)
+
+// CacheEntry is a single cached Fetcher result.
+type CacheEntry struct {
+	Key    string
+	Artist string
+	Title  string
+	// Synced reports whether Lyrics are
+	// synced lyrics in the LRC format.
+	Synced   bool
+	Lyrics   string
+	Provider string
+	// LRC, URL, Copyright and Language are set
+	// for the QueryFetcher Result entries, which
+	// keep both the plain Lyrics and synced LRC.
+	LRC       string `json:",omitempty"`
+	URL       string `json:",omitempty"`
+	Copyright string `json:",omitempty"`
+	Language  string `json:",omitempty"`
+	// Confidence and Versions of the Result, the
+	// versions keep only the Result fields.
+	Confidence float64      `json:",omitempty"`
+	Versions   []CacheEntry `json:",omitempty"`
+	// NotFound entries cache the ErrLyricsNotFound result.
+	NotFound bool
+	// Instrumental entries cache the Instrumental *Error
+	// of the QueryFetcher, for the whole TTL.
+	Instrumental bool `json:",omitempty"`
+	StoredAt     time.Time
+	ExpiresAt    time.Time
+}
+
+// Expired reports whether the entry expired at the given moment.
+func (e *CacheEntry) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// Cache is a Fetcher and QueryFetcher decorator
+// caching the results of the wrapped Fetcher.
+//
+// Entries are kept in memory up to the MaxEntries
+// limit, least recently used are evicted first.
+// If Dir is set entries are also stored on disk,
+// one file per entry, so they survive restarts.
+//
+// Found lyrics and instrumental tracks are cached for TTL,
+// ErrLyricsNotFound results for NegativeTTL.
+// Other errors are not cached.
+type Cache struct {
+	Fetcher     Fetcher
+	Dir         string
+	MaxEntries  int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	// now is time.Now, replaced in tests.
+	now func() time.Time
+}
+
+// NewCache returns Cache around the Fetcher storing
+// entries in the dir with the default limits.
+// Empty dir makes the cache memory only.
+func NewCache(f Fetcher, dir string) *Cache {
+	return &Cache{
+		Fetcher:     f,
+		Dir:         dir,
+		MaxEntries:  DefaultCacheEntries,
+		TTL:         DefaultCacheTTL,
+		NegativeTTL: DefaultNegativeTTL,
+	}
+}
+
+// Cache entry kinds, part of the key.
+const (
+	plainKind  = "plain"
+	syncedKind = "synced"
+	resultKind = "result"
+)
+
+// cacheKeys returns keys the entry is looked up with,
+// by the track id first if it's known.
+func cacheKeys(kind, id, author, title string) []string {
+	keys := []string{}
+	if id != "" {
+		keys = append(keys, kind+"|id:"+id)
+	}
+	// Folding makes trivial differences hit the cache.
+	return append(keys, kind+"|"+Fold(author)+"|"+Fold(title))
+}
+
+// FetchLyrics implements Fetcher.
+func (c *Cache) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := c.FetchTrackLyrics("", author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns
+// name of the provider which found them.
+func (c *Cache) FetchLyricsFrom(author, title string) (string, string, error) {
+	return c.FetchTrackLyrics("", author, title)
+}
+
+// FetchTrackLyrics fetches lyrics of the spotify track
+// with the given id, caching them under the id as well.
+// Returns the lyrics and name of the provider which found them.
+func (c *Cache) FetchTrackLyrics(id, author, title string) (string, string, error) {
+	keys := cacheKeys(plainKind, id, author, title)
+	if e := c.lookup(keys); e != nil {
+		if e.NotFound {
+			return "", "", ErrLyricsNotFound
+		}
+		return e.Lyrics, e.Provider, nil
+	}
+	var lyrics, provider string
+	var err error
+	if sf, ok := c.Fetcher.(sourceFetcher); ok {
+		lyrics, provider, err = sf.FetchLyricsFrom(author, title)
+	} else {
+		lyrics, err = c.Fetcher.FetchLyrics(author, title)
+	}
+	c.store(keys, author, title, false, lyrics, provider, err)
+	return lyrics, provider, err
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+// If the wrapped Fetcher is not a SyncedFetcher
+// ErrLyricsNotFound is returned.
+func (c *Cache) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := c.FetchTrackSyncedLyrics("", author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom fetches the synced lyrics and
+// returns name of the provider which found them.
+func (c *Cache) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	return c.FetchTrackSyncedLyrics("", author, title)
+}
+
+// FetchTrackSyncedLyrics is FetchTrackLyrics for
+// the synced lyrics.
+func (c *Cache) FetchTrackSyncedLyrics(id, author, title string) (*Lyrics, string, error) {
+	sf, ok := c.Fetcher.(SyncedFetcher)
+	if !ok {
+		return nil, "", ErrLyricsNotFound
+	}
+	keys := cacheKeys(syncedKind, id, author, title)
+	if e := c.lookup(keys); e != nil {
+		if e.NotFound {
+			return nil, "", ErrLyricsNotFound
+		}
+		lyrics, err := ParseLRC(strings.NewReader(e.Lyrics))
+		return lyrics, e.Provider, err
+	}
+	var lyrics *Lyrics
+	var provider string
+	var err error
+	if ssf, ok := c.Fetcher.(syncedSourceFetcher); ok {
+		lyrics, provider, err = ssf.FetchSyncedLyricsFrom(author, title)
+	} else {
+		lyrics, err = sf.FetchSyncedLyrics(author, title)
+	}
+	lrc := ""
+	if err == nil {
+		lrc = lyrics.LRC()
+	}
+	c.store(keys, author, title, true, lrc, provider, err)
+	return lyrics, provider, err
+}
+
+// Fetch implements QueryFetcher caching the Results
+// under the SpotifyID of the query as well.
+// The wrapped Fetcher is adapted with Adapt
+// if it's not a QueryFetcher.
+func (c *Cache) Fetch(ctx context.Context, q Query) (*Result, error) {
+	keys := cacheKeys(resultKind, q.SpotifyID, q.Artist(), q.Title)
+	if e := c.lookup(keys); e != nil {
+		if e.NotFound {
+			return nil, &Error{Kind: NotFound, Provider: e.Provider}
+		}
+		if e.Instrumental {
+			return nil, &Error{Kind: Instrumental, Provider: e.Provider}
+		}
+		return e.result()
+	}
+	r, err := Adapt(c.Fetcher).Fetch(ctx, q)
+	e := CacheEntry{Artist: q.Artist(), Title: q.Title}
+	switch {
+	case err == nil:
+		e.setResult(r)
+	case KindOf(err) == NotFound:
+		e.NotFound = true
+	case KindOf(err) == Instrumental:
+		e.Instrumental = true
+		var qe *Error
+		if errors.As(err, &qe) {
+			e.Provider = qe.Provider
+		}
+	default:
+		return r, err
+	}
+	c.putAll(keys, e)
+	return r, err
+}
+
+// setResult stores the Result in the entry.
+func (e *CacheEntry) setResult(r *Result) {
+	e.Lyrics = r.Lyrics
+	e.Provider = r.Provider
+	e.URL = r.URL
+	e.Copyright = r.Copyright
+	e.Language = r.Language
+	e.Confidence = r.Confidence
+	if r.Synced != nil {
+		e.Synced = true
+		e.LRC = r.Synced.LRC()
+	}
+	for _, v := range r.Versions {
+		var version CacheEntry
+		version.setResult(v)
+		e.Versions = append(e.Versions, version)
+	}
+}
+
+// result returns the Result stored in the entry.
+func (e *CacheEntry) result() (*Result, error) {
+	r := &Result{
+		Lyrics:     e.Lyrics,
+		Provider:   e.Provider,
+		URL:        e.URL,
+		Copyright:  e.Copyright,
+		Language:   e.Language,
+		Confidence: e.Confidence,
+	}
+	if e.LRC != "" {
+		synced, err := ParseLRC(strings.NewReader(e.LRC))
+		if err != nil {
+			return nil, err
+		}
+		r.Synced = synced
+	}
+	for i := range e.Versions {
+		v, err := e.Versions[i].result()
+		if err != nil {
+			return nil, err
+		}
+		r.Versions = append(r.Versions, v)
+	}
+	return r, nil
+}
+
+func (c *Cache) timeNow() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// lookup returns the first not expired entry
+// stored under any of the keys, nil if there is none.
+func (c *Cache) lookup(keys []string) *CacheEntry {
+	now := c.timeNow()
+	for _, key := range keys {
+		e := c.get(key)
+		if e == nil {
+			continue
+		}
+		if e.Expired(now) {
+			c.remove(key)
+			continue
+		}
+		return e
+	}
+	return nil
+}
+
+// get returns the entry from memory or
+// from disk, nil if there is none.
+func (c *Cache) get(key string) *CacheEntry {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*CacheEntry)
+	}
+	c.mu.Unlock()
+	if c.Dir == "" {
+		return nil
+	}
+	e, err := readCacheEntry(c.entryPath(key))
+	if err != nil {
+		return nil
+	}
+	c.remember(e)
+	return e
+}
+
+func (c *Cache) store(keys []string, author, title string, synced bool, lyrics, provider string, err error) {
+	if err != nil && err != ErrLyricsNotFound {
+		return
+	}
+	c.putAll(keys, CacheEntry{
+		Artist:   author,
+		Title:    title,
+		Synced:   synced,
+		Lyrics:   lyrics,
+		Provider: provider,
+		NotFound: err == ErrLyricsNotFound,
+	})
+}
+
+// putAll puts the entry under each of the keys,
+// so it's found by the artist and title too.
+func (c *Cache) putAll(keys []string, e CacheEntry) {
+	for _, key := range keys {
+		entry := e
+		entry.Key = key
+		c.put(&entry)
+	}
+}
+
+// put stores the entry in memory and on disk
+// setting its storage and expiration times.
+func (c *Cache) put(e *CacheEntry) {
+	now := c.timeNow()
+	e.StoredAt = now
+	if e.NotFound {
+		e.ExpiresAt = now.Add(c.NegativeTTL)
+	} else {
+		e.ExpiresAt = now.Add(c.TTL)
+	}
+	c.remember(e)
+	if c.Dir != "" {
+		if err := writeCacheEntry(c.Dir, c.entryPath(e.Key), e); err != nil {
+			// Cache failures shouldn't stop the lyrics from being shown.
+			log.Printf("Could not store lyrics in cache: %s", err)
+		}
+	}
+}
+
+// remember puts the entry into memory evicting
+// the least recently used ones over the limit.
+func (c *Cache) remember(e *CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+		c.lru = list.New()
+	}
+	if el, ok := c.entries[e.Key]; ok {
+		el.Value = e
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[e.Key] = c.lru.PushFront(e)
+	for c.MaxEntries > 0 && c.lru.Len() > c.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*CacheEntry).Key)
+	}
+}
+
+func (c *Cache) remove(key string) {
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+	if c.Dir != "" {
+		os.Remove(c.entryPath(key))
+	}
+}
+
+func (c *Cache) entryPath(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Entries returns all of the entries stored on disk.
+func (c *Cache) Entries() ([]CacheEntry, error) {
+	files, err := ioutil.ReadDir(c.Dir)
+	if os.IsNotExist(err) {
+		return []CacheEntry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := []CacheEntry{}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		e, err := readCacheEntry(filepath.Join(c.Dir, f.Name()))
+		if err != nil {
+			continue
+		}
+		entries = append(entries, *e)
+	}
+	return entries, nil
+}
+
+// Purge removes the entries for which match returns
+// true, from memory and disk. Nil match removes all of them.
+// Returns the number of entries removed from disk.
+func (c *Cache) Purge(match func(e CacheEntry) bool) (int, error) {
+	c.mu.Lock()
+	for key, el := range c.entries {
+		if match == nil || match(*el.Value.(*CacheEntry)) {
+			c.lru.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+	c.mu.Unlock()
+	if c.Dir == "" {
+		return 0, nil
+	}
+	entries, err := c.Entries()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if match != nil && !match(e) {
+			continue
+		}
+		if err := os.Remove(c.entryPath(e.Key)); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// PurgeTrack removes the entries of the track of
+// the query, cached by its id or artist and title, so
+// its lyrics are fetched again. Returns the number
+// of entries removed from disk.
+func (c *Cache) PurgeTrack(q Query) (int, error) {
+	keys := map[string]bool{}
+	for _, kind := range []string{plainKind, syncedKind, resultKind} {
+		for _, key := range cacheKeys(kind, q.SpotifyID, q.Artist(), q.Title) {
+			keys[key] = true
+		}
+	}
+	return c.Purge(func(e CacheEntry) bool {
+		return keys[e.Key]
+	})
+}
+
+func readCacheEntry(path string) (*CacheEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var e CacheEntry
+	if err = json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func writeCacheEntry(dir, path string, e *CacheEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
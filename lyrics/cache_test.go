@@ -0,0 +1,117 @@
+package lyrics
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheHitsAndPersists(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lyrics-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &stubFetcher{lyrics: "la la"}
+	c := NewCache(f, dir)
+	for i := 0; i < 2; i++ {
+		l, err := c.FetchLyrics("Artist", "Title")
+		if err != nil || l != "la la" {
+			t.Fatalf("Got %q, %v", l, err)
+		}
+	}
+	// Normalized key hits as well.
+	c.FetchLyrics(" artist ", "TITLE")
+	if f.calls != 1 {
+		t.Errorf("Fetcher called %d times, expected once", f.calls)
+	}
+
+	restarted := NewCache(f, dir)
+	if l, _ := restarted.FetchLyrics("Artist", "Title"); l != "la la" || f.calls != 1 {
+		t.Errorf("Cache didn't survive restart, got %q after %d calls", l, f.calls)
+	}
+}
+
+func TestCacheNegativeTTL(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := &stubFetcher{err: ErrLyricsNotFound}
+	c := NewCache(f, "")
+	c.now = func() time.Time { return now }
+
+	c.FetchLyrics("A", "T")
+	if _, err := c.FetchLyrics("A", "T"); err != ErrLyricsNotFound || f.calls != 1 {
+		t.Errorf("Not found result not cached, %d calls, err %v", f.calls, err)
+	}
+	now = now.Add(DefaultNegativeTTL)
+	c.FetchLyrics("A", "T")
+	if f.calls != 2 {
+		t.Errorf("Not found result didn't expire, %d calls", f.calls)
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	f := &stubFetcher{lyrics: "x"}
+	c := NewCache(f, "")
+	c.MaxEntries = 2
+	c.FetchLyrics("A", "1")
+	c.FetchLyrics("A", "2")
+	c.FetchLyrics("A", "1")
+	c.FetchLyrics("A", "3")
+	calls := f.calls
+	c.FetchLyrics("A", "1")
+	if f.calls != calls {
+		t.Errorf("Recently used entry evicted")
+	}
+	c.FetchLyrics("A", "2")
+	if f.calls != calls+1 {
+		t.Errorf("Least recently used entry not evicted")
+	}
+}
+
+func TestCacheTrackID(t *testing.T) {
+	f := &stubFetcher{lyrics: "x"}
+	c := NewCache(f, "")
+	song := SongInfo{TrackID: "id", Author: "A", Title: "T"}
+	song.FetchLyrics(c)
+	renamed := SongInfo{TrackID: "id", Author: "A", Title: "T - Remastered"}
+	renamed.FetchLyrics(c)
+	if f.calls != 1 || renamed.Lyrics != "x" {
+		t.Errorf("Track id not used as cache key, %d calls", f.calls)
+	}
+	if l, err := c.FetchLyrics(" a ", "t"); err != nil || l != "x" || f.calls != 1 {
+		t.Errorf("Lyrics fetched by the id not cached by the artist and title, got %q after %d calls", l, f.calls)
+	}
+
+	qs := &queryStub{result: &Result{Lyrics: "y", Provider: "lrclib"}}
+	qc := NewCache(qs, "")
+	qc.Fetch(context.Background(), Query{Artists: []string{"A"}, Title: "T", SpotifyID: "id"})
+	r, err := qc.Fetch(context.Background(), Query{Artists: []string{"A"}, Title: "T"})
+	if err != nil || r.Lyrics != "y" || qs.calls != 1 {
+		t.Errorf("Result fetched by the id not cached by the artist and title, got %+v after %d calls", r, qs.calls)
+	}
+}
+
+func TestCachePurgeTrack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lyrics-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &stubFetcher{lyrics: "x"}
+	c := NewCache(f, dir)
+	(&SongInfo{TrackID: "id", Author: "A", Title: "T"}).FetchLyrics(c)
+	c.FetchLyrics("A", "Other")
+	removed, err := c.PurgeTrack(Query{Artists: []string{"A"}, Title: "T", SpotifyID: "id"})
+	if err != nil || removed != 2 {
+		t.Fatalf("expected the id and name entries purged, got %d, %v", removed, err)
+	}
+	c.FetchLyrics("A", "T")
+	c.FetchLyrics("A", "Other")
+	if f.calls != 3 {
+		t.Errorf("expected only the purged track fetched again, %d calls", f.calls)
+	}
+}
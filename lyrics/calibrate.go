@@ -0,0 +1,85 @@
+package lyrics
+
+import (
+	"time"
+)
+
+// Calibrate limits.
+const (
+	// CalibrationTolerance is how much the [length] of the
+	// lyrics can differ from the track duration before
+	// the timings are scaled.
+	CalibrationTolerance = time.Second
+	// MaxCalibrationScale is how much the timings can be
+	// stretched or shrunk. Lyrics differing more are
+	// of another version of the song, like the extended
+	// one, and scaling them would make it worse.
+	MaxCalibrationScale = 1.15
+)
+
+// Calibrate aligns the line timings of the synced lyrics
+// to the duration of the track. If the [length] tag of
+// the lyrics differs from the duration, as when the lyrics
+// were timed to the sped up or slowed down release, timings
+// are scaled to fit the duration and the tag is updated.
+// Lyrics without the tag are left as they are.
+// Returns whether the timings were changed.
+func Calibrate(l *Lyrics, duration time.Duration) bool {
+	length := l.Length()
+	if length <= 0 || duration <= 0 || !l.Synced() {
+		return false
+	}
+	diff := length - duration
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff <= CalibrationTolerance {
+		return false
+	}
+	factor := float64(duration) / float64(length)
+	if factor > MaxCalibrationScale || factor < 1/MaxCalibrationScale {
+		return false
+	}
+	Scale(l, factor)
+	l.SetLength(duration)
+	return true
+}
+
+// Scale multiplies the line and word timings
+// of the lyrics by the factor.
+func Scale(l *Lyrics, factor float64) {
+	scale := func(d time.Duration) time.Duration {
+		if d == NoTime {
+			return d
+		}
+		return time.Duration(float64(d) * factor)
+	}
+	for i, line := range l.Lines {
+		l.Lines[i].Time = scale(line.Time)
+		for j, w := range line.Words {
+			line.Words[j].Time = scale(w.Time)
+		}
+	}
+}
+
+// NearestLine returns index of the synced line starting
+// closest to the given song progress, taking Offset
+// into account. Returns -1 if the lyrics are not synced.
+func (l *Lyrics) NearestLine(progress time.Duration) int {
+	progress += l.Offset
+	nearest := -1
+	var best time.Duration
+	for i, line := range l.Lines {
+		if line.Time == NoTime {
+			continue
+		}
+		d := line.Time - progress
+		if d < 0 {
+			d = -d
+		}
+		if nearest < 0 || d < best {
+			nearest, best = i, d
+		}
+	}
+	return nearest
+}
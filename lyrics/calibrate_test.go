@@ -0,0 +1,69 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalibrate(t *testing.T) {
+	cases := []struct {
+		name     string
+		lrc      string
+		duration time.Duration
+		scaled   bool
+		last     time.Duration
+	}{
+		{"slowed down release", "[length:04:00]\n[01:00.00]a\n[03:00.00]b\n", 220 * time.Second, true, 165 * time.Second},
+		{"sped up release", "[length:03:00]\n[02:00.00]b\n", 200 * time.Second, true, 133333333333},
+		{"within tolerance", "[length:03:00.50]\n[02:00.00]b\n", 3 * time.Minute, false, 2 * time.Minute},
+		{"other version", "[length:06:00]\n[02:00.00]b\n", 3 * time.Minute, false, 2 * time.Minute},
+		{"no length", "[02:00.00]b\n", 3 * time.Minute, false, 2 * time.Minute},
+		{"unknown duration", "[length:04:00]\n[02:00.00]b\n", 0, false, 2 * time.Minute},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, _ := ParseLRC(strings.NewReader(c.lrc))
+			if scaled := Calibrate(l, c.duration); scaled != c.scaled {
+				t.Errorf("scaled %v expected %v", scaled, c.scaled)
+			}
+			if last := l.Lines[len(l.Lines)-1].Time; last != c.last {
+				t.Errorf("last line at %s expected %s", last, c.last)
+			}
+			if c.scaled && l.Length() != c.duration {
+				t.Errorf("length tag %q not updated", l.Tags[TagLength])
+			}
+		})
+	}
+}
+
+func TestScaleKeepsUntimedWords(t *testing.T) {
+	l := &Lyrics{Lines: []Line{
+		{Time: 10 * time.Second, Text: "a b", Words: []Word{{10 * time.Second, "a"}, {NoTime, "b"}}},
+	}}
+	Scale(l, 0.5)
+	if l.Lines[0].Time != 5*time.Second || l.Lines[0].Words[0].Time != 5*time.Second || l.Lines[0].Words[1].Time != NoTime {
+		t.Errorf("got %+v", l.Lines[0])
+	}
+}
+
+func TestNearestLine(t *testing.T) {
+	l, _ := ParseLRC(strings.NewReader("[offset:+1000]\n[00:10.00]a\n[00:20.00]b\n[00:30.00]c\n"))
+	cases := []struct {
+		progress time.Duration
+		expected int
+	}{
+		{0, 0},
+		{13 * time.Second, 0},
+		{15 * time.Second, 1},
+		{40 * time.Second, 2},
+	}
+	for _, c := range cases {
+		if got := l.NearestLine(c.progress); got != c.expected {
+			t.Errorf("at %s got line %d expected %d", c.progress, got, c.expected)
+		}
+	}
+	if got := PlainLyrics("a\nb").NearestLine(0); got != -1 {
+		t.Errorf("expected no line of unsynced lyrics got %d", got)
+	}
+}
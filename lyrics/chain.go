@@ -0,0 +1,284 @@
+package lyrics
+
+import (
+	"context"
+	"log"
+)
+
+// Provider is a Fetcher with the name
+// it is configured and reported by.
+type Provider struct {
+	Name    string
+	Fetcher Fetcher
+}
+
+// Chain is a Fetcher asking several providers
+// for the lyrics in the configured order.
+//
+// ErrLyricsNotFound from a provider means the next one
+// is tried, the first found lyrics are returned.
+// Other errors are logged and the next provider is tried
+// as well, but if no provider found the lyrics the last of
+// these errors is returned instead of ErrLyricsNotFound.
+//
+// In the Race mode all of the providers are asked at
+// once and the first found lyrics are returned.
+// Order of the providers doesn't matter then.
+//
+// Chain is a QueryFetcher as well, then the providers which
+// are not QueryFetchers are adapted with Adapt. Providers
+// saying the track is Instrumental don't stop the Chain,
+// but if no other one found the lyrics their error is returned.
+// If the Query has the expected Language, lyrics detected
+// to be in another one are returned only if no provider
+// found the lyrics in it.
+//
+// With Versions above one the QueryFetcher Chain asks the
+// next providers after the lyrics are found, until it has
+// that many versions of them, and returns the other ones
+// as the Result Versions.
+type Chain struct {
+	Providers []Provider
+	Race      bool
+	Versions  int
+}
+
+// NewChain returns Chain of the given providers.
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{Providers: providers}
+}
+
+// FetchLyrics implements Fetcher.
+func (c *Chain) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := c.FetchLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns name
+// of the provider which found them.
+func (c *Chain) FetchLyricsFrom(author, title string) (string, string, error) {
+	fetch := func(p Provider) (interface{}, error) {
+		return p.Fetcher.FetchLyrics(author, title)
+	}
+	lyrics, provider, err := c.fetch(c.Providers, fetch)
+	if err != nil {
+		return "", "", err
+	}
+	return lyrics.(string), provider, nil
+}
+
+// FetchSyncedLyrics implements SyncedFetcher asking
+// only the providers which implement it.
+func (c *Chain) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := c.FetchSyncedLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom fetches the synced lyrics and
+// returns name of the provider which found them.
+func (c *Chain) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	synced := []Provider{}
+	for _, p := range c.Providers {
+		if _, ok := p.Fetcher.(SyncedFetcher); ok {
+			synced = append(synced, p)
+		}
+	}
+	fetch := func(p Provider) (interface{}, error) {
+		return p.Fetcher.(SyncedFetcher).FetchSyncedLyrics(author, title)
+	}
+	lyrics, provider, err := c.fetch(synced, fetch)
+	if err != nil {
+		return nil, "", err
+	}
+	return lyrics.(*Lyrics), provider, nil
+}
+
+// Fetch implements QueryFetcher. In the Race mode the
+// slower providers are cancelled once the lyrics are found.
+func (c *Chain) Fetch(ctx context.Context, q Query) (*Result, error) {
+	if c.Race {
+		return c.raceQuery(ctx, q)
+	}
+	errs := queryErrors{}
+	found := foundVersions{q: q, versions: c.Versions}
+	for _, p := range c.Providers {
+		r, err := Adapt(p.Fetcher).Fetch(ctx, q)
+		if err == nil {
+			if found.add(p.Name, r) {
+				break
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs.add(p.Name, err)
+	}
+	if r := found.result(); r != nil {
+		return r, nil
+	}
+	return nil, errs.err()
+}
+
+func (c *Chain) raceQuery(ctx context.Context, q Query) (*Result, error) {
+	// Cancels the providers still running when returning.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type queryResult struct {
+		result   *Result
+		provider string
+		err      error
+	}
+	results := make(chan queryResult, len(c.Providers))
+	for _, p := range c.Providers {
+		go func(p Provider) {
+			r, err := Adapt(p.Fetcher).Fetch(ctx, q)
+			results <- queryResult{r, p.Name, err}
+		}(p)
+	}
+	errs := queryErrors{}
+	found := foundVersions{q: q, versions: c.Versions}
+	for range c.Providers {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case r := <-results:
+			if r.err != nil {
+				errs.add(r.provider, r.err)
+				continue
+			}
+			if found.add(r.provider, r.result) {
+				return found.result(), nil
+			}
+		}
+	}
+	if r := found.result(); r != nil {
+		return r, nil
+	}
+	return nil, errs.err()
+}
+
+// foundVersions collects the lyrics found by the
+// providers of the Chain for the query.
+type foundVersions struct {
+	q        Query
+	versions int
+	// matching are the lyrics in the expected
+	// language, other are the rest of them.
+	matching []*Result
+	other    []*Result
+}
+
+// add adds the lyrics found by the provider with their
+// versions. Returns whether there are enough versions,
+// one of them in the expected language. The versions
+// are copied, the Results of the providers are left as
+// they are, they can be cached.
+func (f *foundVersions) add(provider string, r *Result) bool {
+	for _, version := range r.AllVersions() {
+		v := *version
+		v.Versions = nil
+		v.Provider = provider
+		v.detectLanguage()
+		if v.matches(f.q) {
+			f.matching = append(f.matching, &v)
+		} else {
+			f.other = append(f.other, &v)
+		}
+	}
+	return len(f.matching) > 0 && len(f.matching)+len(f.other) >= f.versions
+}
+
+// result returns the preferred lyrics with the other
+// ones as the Versions, nil if none were found.
+func (f *foundVersions) result() *Result {
+	return withVersions(append(append([]*Result{}, f.matching...), f.other...))
+}
+
+// queryErrors collects the errors of the providers
+// which didn't find the lyrics.
+type queryErrors struct {
+	instrumental error
+	last         error
+}
+
+func (e *queryErrors) add(provider string, err error) {
+	err = queryError(provider, err)
+	switch KindOf(err) {
+	case NotFound:
+	case Instrumental:
+		e.instrumental = err
+	default:
+		log.Printf("Lyrics provider %s failed: %s", provider, err)
+		e.last = err
+	}
+}
+
+// err returns the error of the whole Chain.
+func (e *queryErrors) err() error {
+	if e.instrumental != nil {
+		return e.instrumental
+	}
+	if e.last != nil {
+		return e.last
+	}
+	return &Error{Kind: NotFound}
+}
+
+type fetchFunc func(p Provider) (interface{}, error)
+
+func (c *Chain) fetch(providers []Provider, fetch fetchFunc) (interface{}, string, error) {
+	if c.Race {
+		return race(providers, fetch)
+	}
+	var lastErr error
+	for _, p := range providers {
+		lyrics, err := fetch(p)
+		if err == nil {
+			return lyrics, p.Name, nil
+		}
+		if err != ErrLyricsNotFound {
+			log.Printf("Lyrics provider %s failed: %s", p.Name, err)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return nil, "", lastErr
+	}
+	return nil, "", ErrLyricsNotFound
+}
+
+type raceResult struct {
+	lyrics   interface{}
+	provider string
+	err      error
+}
+
+// race asks all of the providers at once and returns
+// the first found lyrics. Fetchers can't be interrupted,
+// so the slower ones finish in the background and
+// their results are dropped. Fetch cancels them instead.
+func race(providers []Provider, fetch fetchFunc) (interface{}, string, error) {
+	results := make(chan raceResult, len(providers))
+	for _, p := range providers {
+		go func(p Provider) {
+			lyrics, err := fetch(p)
+			results <- raceResult{lyrics, p.Name, err}
+		}(p)
+	}
+	var lastErr error
+	for range providers {
+		r := <-results
+		if r.err == nil {
+			return r.lyrics, r.provider, nil
+		}
+		if r.err != ErrLyricsNotFound {
+			log.Printf("Lyrics provider %s failed: %s", r.provider, r.err)
+			lastErr = r.err
+		}
+	}
+	if lastErr != nil {
+		return nil, "", lastErr
+	}
+	return nil, "", ErrLyricsNotFound
+}
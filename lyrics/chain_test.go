@@ -0,0 +1,63 @@
+package lyrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type stubFetcher struct {
+	lyrics string
+	err    error
+	delay  time.Duration
+	calls  int
+}
+
+func (f *stubFetcher) FetchLyrics(author, title string) (string, error) {
+	f.calls++
+	time.Sleep(f.delay)
+	return f.lyrics, f.err
+}
+
+func TestChainFallsBack(t *testing.T) {
+	first := &stubFetcher{err: ErrLyricsNotFound}
+	second := &stubFetcher{lyrics: "found"}
+	third := &stubFetcher{lyrics: "never asked"}
+	c := NewChain(Provider{"first", first}, Provider{"second", second}, Provider{"third", third})
+
+	song := SongInfo{Author: "A", Title: "T"}
+	if err := song.FetchLyrics(c); err != nil {
+		t.Fatal(err)
+	}
+	if song.Lyrics != "found" || song.Provider != "second" || third.calls != 0 {
+		t.Errorf("Got %q from %q, third called %d times", song.Lyrics, song.Provider, third.calls)
+	}
+}
+
+func TestChainErrors(t *testing.T) {
+	down := errors.New("provider down")
+	c := NewChain(
+		Provider{"down", &stubFetcher{err: down}},
+		Provider{"missing", &stubFetcher{err: ErrLyricsNotFound}},
+	)
+	if _, err := c.FetchLyrics("A", "T"); err != down {
+		t.Errorf("Expected provider error got %v", err)
+	}
+	c = NewChain(Provider{"missing", &stubFetcher{err: ErrLyricsNotFound}})
+	if _, err := c.FetchLyrics("A", "T"); err != ErrLyricsNotFound {
+		t.Errorf("Expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestChainRace(t *testing.T) {
+	c := NewChain(
+		Provider{"slow", &stubFetcher{lyrics: "slow", delay: time.Second}},
+		Provider{"missing", &stubFetcher{err: ErrLyricsNotFound}},
+		Provider{"fast", &stubFetcher{lyrics: "fast"}},
+	)
+	c.Race = true
+	lyrics, provider, err := c.FetchLyricsFrom("A", "T")
+	if err != nil || lyrics != "fast" || provider != "fast" {
+		t.Errorf("Got %q from %q, err %v", lyrics, provider, err)
+	}
+}
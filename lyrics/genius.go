@@ -0,0 +1,204 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// GeniusURL is the default GeniusFetcher api base url.
+const GeniusURL = "https://api.genius.com"
+
+// ErrNoToken is the cause of the ProviderDown *Error
+// of the GeniusFetcher which Token is not set.
+var ErrNoToken = errors.New("genius api token is not set")
+
+// GeniusFetcher is a QueryFetcher and Fetcher
+// using the Genius like api.
+//
+// The song is searched for with the token authenticated api,
+// hits are narrowed down to the ones by the songs primary
+// artist and the best match is picked with the Matcher.
+// Lyrics are scraped from the song page the hit links to,
+// section headers like [Chorus] are kept.
+type GeniusFetcher struct {
+	// BaseURL of the api, GeniusURL if empty.
+	BaseURL string
+	// Token is the api client access token.
+	Token string
+	// Client makes the requests,
+	// http.DefaultClient if nil.
+	Client *http.Client
+	// Matcher picks the search result,
+	// DefaultMatcher if nil.
+	Matcher *Matcher
+}
+
+type geniusSong struct {
+	ID            int    `json:"id"`
+	Title         string `json:"title"`
+	URL           string `json:"url"`
+	PrimaryArtist struct {
+		Name string `json:"name"`
+	} `json:"primary_artist"`
+}
+
+type geniusSearchResponse struct {
+	Response struct {
+		Hits []struct {
+			Type   string     `json:"type"`
+			Result geniusSong `json:"result"`
+		} `json:"hits"`
+	} `json:"response"`
+}
+
+// geniusName is the provider name in the errors and results.
+const geniusName = "genius"
+
+// Fetch implements QueryFetcher.
+func (f GeniusFetcher) Fetch(ctx context.Context, q Query) (*Result, error) {
+	hits, err := f.search(ctx, q.Artist(), q.Title)
+	if err != nil {
+		return nil, err
+	}
+	m := DefaultMatcher
+	if f.Matcher != nil {
+		m = *f.Matcher
+	}
+	hit, score, err := m.Best(q.song(), primaryArtistHits(q.Artist(), hits))
+	if err != nil {
+		return nil, &Error{Kind: NotFound, Provider: geniusName, Err: err}
+	}
+	doc, err := getPage(ctx, f.Client, geniusName, hit.Ref)
+	if err != nil {
+		return nil, err
+	}
+	lyrics := geniusLyrics(doc)
+	if lyrics == "" {
+		return nil, &Error{Kind: NotFound, Provider: geniusName}
+	}
+	r := newResult(geniusName, lyrics, nil)
+	r.URL = hit.Ref
+	r.Confidence = score
+	return r, nil
+}
+
+// FetchLyrics implements Fetcher.
+func (f GeniusFetcher) FetchLyrics(author, title string) (string, error) {
+	r, err := f.Fetch(context.Background(), Query{Artists: []string{author}, Title: title})
+	if err != nil {
+		return "", fetcherError(err)
+	}
+	return r.Lyrics, nil
+}
+
+// primaryArtistHits returns the hits by the main artist
+// of the song, so covers and the songs the artist only
+// features on are skipped. If there are none all
+// of the hits are returned for the Matcher to judge.
+func primaryArtistHits(author string, hits []Hit) []Hit {
+	main := Fold(MainArtist(author))
+	primary := []Hit{}
+	for _, h := range hits {
+		if Fold(MainArtist(h.Artist)) == main {
+			primary = append(primary, h)
+		}
+	}
+	if len(primary) == 0 {
+		return hits
+	}
+	return primary
+}
+
+// search returns the song hits of the api search.
+func (f GeniusFetcher) search(ctx context.Context, author, title string) ([]Hit, error) {
+	if f.Token == "" {
+		return nil, &Error{Kind: ProviderDown, Provider: geniusName, Err: ErrNoToken}
+	}
+	base := GeniusURL
+	if f.BaseURL != "" {
+		base = strings.TrimRight(f.BaseURL, "/")
+	}
+	query := url.Values{}
+	query.Set("q", author+" "+title)
+	req, err := http.NewRequest("GET", base+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+f.Token)
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &Error{Kind: ProviderDown, Provider: geniusName, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(geniusName, resp)
+	}
+	var result geniusSearchResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, &Error{Kind: ProviderDown, Provider: geniusName, Err: err}
+	}
+	hits := []Hit{}
+	for _, h := range result.Response.Hits {
+		if h.Type != "song" || h.Result.URL == "" {
+			continue
+		}
+		hits = append(hits, Hit{
+			Artist: h.Result.PrimaryArtist.Name,
+			Title:  h.Result.Title,
+			Ref:    h.Result.URL,
+		})
+	}
+	return hits, nil
+}
+
+// geniusLyrics extracts the lyrics from the song page.
+// They are split into several containers around the ads,
+// annotated fragments are links which text is kept.
+func geniusLyrics(doc *html.Node) string {
+	containers := findAll(doc, func(n *html.Node) bool {
+		return n.Type == html.ElementNode && attr(n, "data-lyrics-container") == "true"
+	})
+	sb := strings.Builder{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case n.Type != html.ElementNode:
+		case n.Data == "br":
+			sb.WriteString("\n")
+			return
+		case n.Data == "script" || n.Data == "style":
+			return
+		case attr(n, "data-exclude-from-selection") == "true":
+			// Headers and ads inside of the container.
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	for _, c := range containers {
+		walk(c)
+		sb.WriteString("\n")
+	}
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,99 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// geniusServer serves the recorded search response with the
+// song urls pointing to itself and the Under Pressure song page.
+func geniusServer(t *testing.T) *httptest.Server {
+	search, err := ioutil.ReadFile(filepath.Join("testdata", "genius_search.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			if r.Header.Get("Authorization") != "Bearer token" {
+				http.Error(w, `{"meta":{"status":401}}`, http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(strings.Replace(string(search), "https://genius.com", server.URL, -1)))
+		case "/Queen-and-david-bowie-under-pressure-lyrics":
+			http.ServeFile(w, r, filepath.Join("testdata", "genius_song.html"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server
+}
+
+func TestGeniusFetcher(t *testing.T) {
+	server := geniusServer(t)
+	defer server.Close()
+	f := GeniusFetcher{BaseURL: server.URL, Token: "token"}
+
+	// Cover with the exact title comes first
+	// but is not by the primary artist.
+	lyrics, err := f.FetchLyrics("Queen, David Bowie", "Under Pressure - Remastered 2011")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	expected := "[Intro]\n" +
+		"Mm ba ba de\n" +
+		"Um bum ba de\n" +
+		"\n" +
+		"[Verse 1: Freddie Mercury]\n" +
+		"Pressure pushing down on me\n" +
+		"Pressing down on you, no man ask for\n" +
+		"Under pressure that burns a building down\n" +
+		"[Chorus: David Bowie & Freddie Mercury]\n" +
+		"It's the terror of knowing\n" +
+		"What the world is about"
+	if lyrics != expected {
+		t.Errorf("got lyrics\n%s\nexpected\n%s", lyrics, expected)
+	}
+}
+
+func TestGeniusFetcherNotFound(t *testing.T) {
+	server := geniusServer(t)
+	defer server.Close()
+	f := GeniusFetcher{BaseURL: server.URL, Token: "token"}
+
+	if _, err := f.FetchLyrics("Metallica", "One"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestGeniusFetcherToken(t *testing.T) {
+	server := geniusServer(t)
+	defer server.Close()
+
+	if _, err := (GeniusFetcher{BaseURL: server.URL}).FetchLyrics("Queen", "Under Pressure"); !errors.Is(err, ErrNoToken) {
+		t.Errorf("expected ErrNoToken got %v", err)
+	}
+	_, err := GeniusFetcher{BaseURL: server.URL, Token: "wrong"}.FetchLyrics("Queen", "Under Pressure")
+	if err == nil || err == ErrLyricsNotFound {
+		t.Errorf("expected the authorization error got %v", err)
+	}
+}
+
+func TestGeniusFetcherBrokenResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>maintenance</html>`))
+	}))
+	defer server.Close()
+	f := GeniusFetcher{BaseURL: server.URL, Token: "token"}
+	if _, err := f.Fetch(context.Background(), Query{Artists: []string{"Queen"}, Title: "Under Pressure"}); KindOf(err) != ProviderDown {
+		t.Errorf("expected ProviderDown got %v", err)
+	}
+}
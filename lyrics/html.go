@@ -0,0 +1,103 @@
+package lyrics
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Helpers for the scrapers walking
+// the parsed html pages.
+
+// getPage fetches and parses the page with the client,
+// http.DefaultClient if nil. Failures are reported
+// as the *Error of the provider.
+func getPage(ctx context.Context, client *http.Client, provider, pageURL string) (*html.Node, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &Error{Kind: ProviderDown, Provider: provider, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(provider, resp)
+	}
+	return html.Parse(resp.Body)
+}
+
+// find returns the first node, depth first,
+// matching the predicate, nil if there is none.
+func find(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if match(n) {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := find(c, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findAll returns all of the nodes matching
+// the predicate in the document order.
+func findAll(n *html.Node, match func(*html.Node) bool) []*html.Node {
+	found := []*html.Node{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if match(n) {
+			found = append(found, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return found
+}
+
+func isElement(tag string) func(*html.Node) bool {
+	return func(n *html.Node) bool {
+		return n.Type == html.ElementNode && n.Data == tag
+	}
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasClass(n *html.Node, class string) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	for _, c := range strings.Fields(attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func textContent(n *html.Node) string {
+	sb := strings.Builder{}
+	for _, t := range findAll(n, func(n *html.Node) bool { return n.Type == html.TextNode }) {
+		sb.WriteString(t.Data)
+	}
+	return sb.String()
+}
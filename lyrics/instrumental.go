@@ -0,0 +1,129 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+)
+
+// DefaultInstrumentalness is the instrumentalness at
+// and above which the track the providers found no
+// lyrics for is taken as instrumental.
+const DefaultInstrumentalness = 0.9
+
+// instrumentalTitleRe matches the "(Instrumental)",
+// "[Instrumental Version]" and " - Instrumental" like
+// title markers.
+var instrumentalTitleRe = regexp.MustCompile(
+	`(?i)(?:[(\[][^)\]]*\binstrumental\b[^)\]]*[)\]]|\s-\s[^-]*\binstrumental\b)`)
+
+// IsInstrumentalTitle reports whether the title marks
+// the instrumental version of the song.
+// Songs titled just "Instrumental" are not marked.
+func IsInstrumentalTitle(title string) bool {
+	return instrumentalTitleRe.MatchString(title)
+}
+
+// Reasons of the Instrumental errors of the detector.
+var (
+	errInstrumentalTitle    = errors.New("title marks the instrumental version")
+	errInstrumentalFeatures = errors.New("audio features say there are no vocals")
+)
+
+// InstrumentalDetector is a Fetcher and QueryFetcher decorator
+// recognizing the instrumental tracks, reported with the
+// Instrumental *Error. Wrap it with the Cache so the
+// instrumental tracks are not looked up again.
+//
+// Tracks with the instrumental title markers are never
+// looked up. Tracks the wrapped Fetcher found no lyrics for
+// are instrumental if the Instrumentalness of the track
+// is at or above the Threshold. Providers can report
+// instrumental tracks themselves as well.
+type InstrumentalDetector struct {
+	Fetcher Fetcher
+	// Instrumentalness returns the instrumentalness,
+	// from 0 to 1, of the spotify track with the given id,
+	// like the one of the spotify audio features.
+	// Nil disables the check.
+	Instrumentalness func(ctx context.Context, spotifyID string) (float64, error)
+	// Threshold is the instrumentalness of the instrumental
+	// tracks, DefaultInstrumentalness if zero.
+	Threshold float64
+}
+
+// NewInstrumentalDetector returns InstrumentalDetector
+// around the Fetcher checking only the titles.
+func NewInstrumentalDetector(f Fetcher) *InstrumentalDetector {
+	return &InstrumentalDetector{Fetcher: f}
+}
+
+// Fetch implements QueryFetcher. The wrapped Fetcher is
+// adapted with Adapt if it's not a QueryFetcher.
+func (d *InstrumentalDetector) Fetch(ctx context.Context, q Query) (*Result, error) {
+	if IsInstrumentalTitle(q.Title) {
+		return nil, &Error{Kind: Instrumental, Err: errInstrumentalTitle}
+	}
+	r, err := Adapt(d.Fetcher).Fetch(ctx, q)
+	if KindOf(err) != NotFound || d.Instrumentalness == nil || q.SpotifyID == "" {
+		return r, err
+	}
+	instrumentalness, ferr := d.Instrumentalness(ctx, q.SpotifyID)
+	if ferr != nil {
+		log.Printf("Couldn't check if the track %s is instrumental: %s", q.SpotifyID, ferr)
+		return r, err
+	}
+	threshold := d.Threshold
+	if threshold == 0 {
+		threshold = DefaultInstrumentalness
+	}
+	if instrumentalness >= threshold {
+		return nil, &Error{Kind: Instrumental, Err: errInstrumentalFeatures}
+	}
+	return r, err
+}
+
+// FetchLyrics implements Fetcher. Only the title
+// is checked, instrumental tracks are reported
+// as ErrLyricsNotFound.
+func (d *InstrumentalDetector) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := d.FetchLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns
+// name of the provider which found them, if the
+// wrapped Fetcher reports it.
+func (d *InstrumentalDetector) FetchLyricsFrom(author, title string) (string, string, error) {
+	if IsInstrumentalTitle(title) {
+		return "", "", ErrLyricsNotFound
+	}
+	if sf, ok := d.Fetcher.(sourceFetcher); ok {
+		return sf.FetchLyricsFrom(author, title)
+	}
+	lyrics, err := d.Fetcher.FetchLyrics(author, title)
+	return lyrics, "", err
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+// If the wrapped Fetcher is not a SyncedFetcher
+// ErrLyricsNotFound is returned.
+func (d *InstrumentalDetector) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := d.FetchSyncedLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom is FetchLyricsFrom for
+// the synced lyrics.
+func (d *InstrumentalDetector) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	sf, ok := d.Fetcher.(SyncedFetcher)
+	if !ok || IsInstrumentalTitle(title) {
+		return nil, "", ErrLyricsNotFound
+	}
+	if ssf, ok := d.Fetcher.(syncedSourceFetcher); ok {
+		return ssf.FetchSyncedLyricsFrom(author, title)
+	}
+	lyrics, err := sf.FetchSyncedLyrics(author, title)
+	return lyrics, "", err
+}
@@ -0,0 +1,64 @@
+package lyrics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIsInstrumentalTitle(t *testing.T) {
+	cases := map[string]bool{
+		"Song (Instrumental)":            true,
+		"Song [Instrumental Version]":    true,
+		"Song - Instrumental":            true,
+		"Song - Remastered Instrumental": true,
+		"Song (feat. X) (instrumental)":  true,
+		"Instrumental":                   false,
+		"Instrumental Song":              false,
+		"Song - Live":                    false,
+	}
+	for title, expected := range cases {
+		if got := IsInstrumentalTitle(title); got != expected {
+			t.Errorf("IsInstrumentalTitle(%q) = %v expected %v", title, got, expected)
+		}
+	}
+}
+
+func TestInstrumentalDetector(t *testing.T) {
+	f := &stubFetcher{err: ErrLyricsNotFound}
+	features := map[string]float64{"instrumental": 0.95, "vocal": 0.2}
+	d := NewInstrumentalDetector(f)
+	d.Instrumentalness = func(ctx context.Context, id string) (float64, error) {
+		return features[id], nil
+	}
+	ctx := context.Background()
+
+	if _, err := d.Fetch(ctx, Query{Title: "Song (Instrumental)"}); KindOf(err) != Instrumental || f.calls != 0 {
+		t.Errorf("title marker: got %v after %d calls", err, f.calls)
+	}
+	if _, err := d.Fetch(ctx, Query{Title: "Song", SpotifyID: "instrumental"}); KindOf(err) != Instrumental {
+		t.Errorf("audio features: expected Instrumental got %v", err)
+	}
+	if _, err := d.Fetch(ctx, Query{Title: "Song", SpotifyID: "vocal"}); KindOf(err) != NotFound {
+		t.Errorf("vocal track: expected NotFound got %v", err)
+	}
+
+	// Found lyrics win over the audio features.
+	f.err, f.lyrics = nil, "la la"
+	if r, err := d.Fetch(ctx, Query{Title: "Song", SpotifyID: "instrumental"}); err != nil || r.Lyrics != "la la" {
+		t.Errorf("got %+v, %v", r, err)
+	}
+}
+
+func TestCacheInstrumental(t *testing.T) {
+	f := &queryStub{err: &Error{Kind: Instrumental, Provider: "lrclib"}}
+	c := NewCache(f, "")
+	q := Query{Artists: []string{"A"}, Title: "T", SpotifyID: "id"}
+	c.Fetch(context.Background(), q)
+
+	f.err = &Error{Kind: ProviderDown}
+	song := SongInfo{Author: "A", Title: "T", TrackID: "id"}
+	err := song.FetchContext(context.Background(), c)
+	if KindOf(err) != Instrumental || !song.Instrumental {
+		t.Errorf("instrumental state not cached, got %v", err)
+	}
+}
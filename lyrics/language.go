@@ -0,0 +1,267 @@
+package lyrics
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Language detection scores the character n-grams of the
+// text with their frequencies in the profiles of the languages,
+// built from the samples below, picking the language most
+// likely to produce them. Languages with their own script
+// are told by the script.
+
+const (
+	// maxNGram is the length of the longest n-grams.
+	maxNGram = 3
+	// minDetectLetters is the number of letters
+	// the text needs for its language to be detected.
+	minDetectLetters = 20
+)
+
+// languageSamples are the texts the language
+// profiles are built from, by the script.
+var languageSamples = map[*unicode.RangeTable]map[string]string{
+	unicode.Latin: {
+		"en": `I don't know what you want from me, but I will be there when the night is over.
+			You said that we could make it if we try, and now the world is turning all around us.
+			Every time I see your face I remember the days we had, the love that we were dreaming of.
+			There is nothing left to say, so hold my hand and never let me go. The people in the street
+			are singing with the wind, they have been waiting for the summer to come home again.
+			It was the best thing that ever happened to me, and I would give it all to have you back.`,
+		"pl": `Nie wiem, czego chcesz ode mnie, ale będę tam, kiedy skończy się noc.
+			Powiedziałaś, że możemy to zrobić, jeśli spróbujemy, a teraz cały świat kręci się wokół nas.
+			Za każdym razem, gdy widzę twoją twarz, przypominam sobie dni, które mieliśmy, i miłość, o której marzyliśmy.
+			Nie ma już nic do powiedzenia, więc trzymaj mnie za rękę i nigdy nie pozwól mi odejść.
+			Ludzie na ulicy śpiewają z wiatrem, czekali na lato, żeby znowu wrócić do domu.
+			To było najlepsze, co mi się przydarzyło, i oddałbym wszystko, żebyś wróciła.`,
+		"de": `Ich weiß nicht, was du von mir willst, aber ich werde da sein, wenn die Nacht vorbei ist.
+			Du hast gesagt, dass wir es schaffen können, wenn wir es versuchen, und jetzt dreht sich die ganze Welt um uns.
+			Jedes Mal, wenn ich dein Gesicht sehe, erinnere ich mich an die Tage, die wir hatten, an die Liebe, von der wir träumten.
+			Es gibt nichts mehr zu sagen, also halt meine Hand und lass mich niemals gehen.
+			Die Menschen auf der Straße singen mit dem Wind, sie haben auf den Sommer gewartet, um wieder nach Hause zu kommen.
+			Es war das Schönste, was mir je passiert ist, und ich würde alles geben, um dich zurückzuhaben.`,
+		"es": `No sé lo que quieres de mí, pero estaré allí cuando termine la noche.
+			Dijiste que podríamos lograrlo si lo intentamos, y ahora el mundo entero gira a nuestro alrededor.
+			Cada vez que veo tu cara recuerdo los días que tuvimos, el amor con el que soñábamos.
+			No queda nada que decir, así que toma mi mano y nunca me dejes ir.
+			La gente en la calle canta con el viento, han estado esperando que llegue el verano para volver a casa.
+			Fue lo mejor que me ha pasado en la vida, y lo daría todo por tenerte de vuelta.`,
+		"fr": `Je ne sais pas ce que tu veux de moi, mais je serai là quand la nuit sera finie.
+			Tu as dit que nous pourrions y arriver si nous essayons, et maintenant le monde entier tourne autour de nous.
+			Chaque fois que je vois ton visage, je me souviens des jours que nous avons eus, de l'amour dont nous rêvions.
+			Il n'y a plus rien à dire, alors prends ma main et ne me laisse jamais partir.
+			Les gens dans la rue chantent avec le vent, ils attendaient que l'été vienne pour rentrer à la maison.
+			C'était la plus belle chose qui me soit arrivée, et je donnerais tout pour que tu reviennes.`,
+		"it": `Non so cosa vuoi da me, ma sarò lì quando la notte sarà finita.
+			Hai detto che potremmo farcela se ci proviamo, e adesso il mondo intero gira intorno a noi.
+			Ogni volta che vedo il tuo viso ricordo i giorni che abbiamo avuto, l'amore che sognavamo.
+			Non c'è più niente da dire, quindi tienimi la mano e non lasciarmi mai andare.
+			La gente nella strada canta con il vento, aspettavano che arrivasse l'estate per tornare a casa.
+			È stata la cosa più bella che mi sia mai successa, e darei tutto per riaverti.`,
+		"pt": `Eu não sei o que você quer de mim, mas estarei lá quando a noite acabar.
+			Você disse que poderíamos conseguir se tentássemos, e agora o mundo inteiro gira ao nosso redor.
+			Cada vez que vejo o seu rosto eu me lembro dos dias que tivemos, do amor com que sonhávamos.
+			Não há mais nada a dizer, então segure a minha mão e nunca me deixe ir.
+			As pessoas na rua cantam com o vento, estavam esperando o verão chegar para voltar para casa.
+			Foi a melhor coisa que já me aconteceu, e eu daria tudo para ter você de volta.`,
+		"nl": `Ik weet niet wat je van me wilt, maar ik zal er zijn als de nacht voorbij is.
+			Je zei dat we het konden halen als we het probeerden, en nu draait de hele wereld om ons heen.
+			Elke keer als ik je gezicht zie, denk ik aan de dagen die we hadden, aan de liefde waarvan we droomden.
+			Er is niets meer te zeggen, dus houd mijn hand vast en laat me nooit meer gaan.
+			De mensen op straat zingen met de wind mee, ze hebben gewacht tot de zomer kwam om weer naar huis te gaan.
+			Het was het mooiste wat me ooit is overkomen, en ik zou alles geven om je terug te hebben.`,
+		"sv": `Jag vet inte vad du vill ha av mig, men jag kommer att vara där när natten är över.
+			Du sa att vi kunde klara det om vi försökte, och nu snurrar hela världen runt oss.
+			Varje gång jag ser ditt ansikte minns jag dagarna vi hade, kärleken som vi drömde om.
+			Det finns inget mer att säga, så håll min hand och släpp mig aldrig.
+			Människorna på gatan sjunger med vinden, de har väntat på att sommaren ska komma hem igen.
+			Det var det bästa som någonsin hänt mig, och jag skulle ge allt för att få tillbaka dig.`,
+	},
+	unicode.Cyrillic: {
+		"ru": `Я не знаю, чего ты хочешь от меня, но я буду рядом, когда закончится ночь.
+			Ты сказала, что у нас всё получится, если мы попробуем, и теперь весь мир кружится вокруг нас.
+			Каждый раз, когда я вижу твоё лицо, я вспоминаю дни, которые у нас были, и любовь, о которой мы мечтали.
+			Больше нечего сказать, так что держи меня за руку и никогда не отпускай.
+			Люди на улице поют вместе с ветром, они ждали, когда придёт лето, чтобы вернуться домой.
+			Это было лучшее, что со мной случилось, и я отдал бы всё, чтобы ты вернулась.
+			Мы шли по берегу реки, и солнце садилось за лесом, а ты смеялась и пела свою песню.
+			Эта песня о любви и разлуке, о том, что нельзя забыть, даже если очень хочется.`,
+		"uk": `Я не знаю, чого ти хочеш від мене, але я буду поруч, коли закінчиться ніч.
+			Ти сказала, що в нас усе вийде, якщо ми спробуємо, і тепер увесь світ кружляє навколо нас.
+			Щоразу, коли я бачу твоє обличчя, я згадую дні, які в нас були, і кохання, про яке ми мріяли.
+			Більше нічого сказати, тож тримай мене за руку і ніколи не відпускай.
+			Люди на вулиці співають разом із вітром, вони чекали, коли прийде літо, щоб повернутися додому.
+			Це було найкраще, що зі мною сталося, і я віддав би все, щоб ти повернулася.
+			Ми йшли берегом річки, і сонце сідало за лісом, а ти сміялася і співала свою пісню.
+			Ця пісня про кохання і розлуку, про те, що не можна забути, навіть якщо дуже хочеться.`,
+	},
+}
+
+// scriptLanguages are the languages
+// told by their own script.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Thai, "th"},
+	{unicode.Han, "zh"},
+}
+
+// languageProfile holds the n-gram counts of the text.
+type languageProfile struct {
+	counts map[string]int
+	total  int
+}
+
+var (
+	profilesOnce sync.Once
+	profiles     map[*unicode.RangeTable]map[string]languageProfile
+)
+
+func languageProfiles() map[*unicode.RangeTable]map[string]languageProfile {
+	profilesOnce.Do(func() {
+		profiles = map[*unicode.RangeTable]map[string]languageProfile{}
+		for script, samples := range languageSamples {
+			profiles[script] = map[string]languageProfile{}
+			for lang, sample := range samples {
+				profiles[script][lang] = newProfile(sample)
+			}
+		}
+	})
+	return profiles
+}
+
+// newProfile returns the profile of the n-grams
+// of the text words.
+func newProfile(text string) languageProfile {
+	p := languageProfile{counts: map[string]int{}}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxNGram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				if gram := string(runes[i : i+n]); gram != " " {
+					p.counts[gram]++
+					p.total++
+				}
+			}
+		}
+	}
+	return p
+}
+
+// logLikelihood is the log probability of the language
+// of the profile producing the n-grams of the text,
+// with the add-one smoothing for the unseen ones.
+func (p languageProfile) logLikelihood(text languageProfile) float64 {
+	l := 0.0
+	unseen := float64(p.total + len(p.counts))
+	for gram, n := range text.counts {
+		l += float64(n) * math.Log(float64(p.counts[gram]+1)/unseen)
+	}
+	return l
+}
+
+// dominantScript returns the script most of the
+// text letters are in and the number of letters.
+// Scripts are the ones of the samples or,
+// if ownScript is set, of the scriptLanguages only.
+func dominantScript(text string, ownScript bool) (*unicode.RangeTable, int) {
+	counts := map[*unicode.RangeTable]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if !ownScript {
+			for script := range languageSamples {
+				if unicode.Is(script, r) {
+					counts[script]++
+				}
+			}
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				counts[s.script]++
+			}
+		}
+	}
+	var dominant *unicode.RangeTable
+	for script, n := range counts {
+		if dominant == nil || n > counts[dominant] {
+			dominant = script
+		}
+	}
+	return dominant, letters
+}
+
+// ScriptLanguage returns the ISO 639-1 code of the
+// language told by the script of the text, like Korean
+// for Hangul, even if the text mixes it with the Latin.
+// Japanese text with kanji is Japanese if there is any
+// kana in it. It works with the short texts like song titles.
+func ScriptLanguage(text string) string {
+	script, _ := dominantScript(text, true)
+	if script == unicode.Han {
+		for _, r := range text {
+			if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+				return "ja"
+			}
+		}
+	}
+	for _, s := range scriptLanguages {
+		if s.script == script {
+			return s.language
+		}
+	}
+	return ""
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language
+// of the text and the confidence, from 0 to 1, of the guess.
+// Empty code is returned for the texts too short to tell
+// or in the languages without the profile.
+func DetectLanguage(text string) (string, float64) {
+	script, letters := dominantScript(text, false)
+	candidates, ok := languageProfiles()[script]
+	if !ok && script != nil {
+		return ScriptLanguage(text), 1
+	}
+	if letters < minDetectLetters || len(candidates) == 0 {
+		return "", 0
+	}
+	p := newProfile(text)
+	best, bestScore, secondScore := "", math.Inf(-1), math.Inf(-1)
+	for lang, profile := range candidates {
+		score := profile.logLikelihood(p)
+		if score > bestScore {
+			best, bestScore, secondScore = lang, score, bestScore
+		} else if score > secondScore {
+			secondScore = score
+		}
+	}
+	if math.IsInf(secondScore, -1) {
+		return best, 1
+	}
+	// Confidence is the probability of the best
+	// language against the second best one.
+	return best, 1 / (1 + math.Exp(secondScore-bestScore))
+}
+
+// LatinScript reports whether the language
+// is known to be written in the Latin script.
+func LatinScript(lang string) bool {
+	_, ok := languageSamples[unicode.Latin][lang]
+	return ok
+}
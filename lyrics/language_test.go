@@ -0,0 +1,68 @@
+package lyrics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	cases := map[string]string{
+		"Is this the real life? Is this just fantasy? Caught in a landslide, no escape from reality": "en",
+		"Przez twe oczy zielone oszalałem, gwiazdy chyba twym oczom oddały blask":                    "pl",
+		"Du hast mich gefragt und ich hab nichts gesagt, willst du bis der Tod euch scheidet":        "de",
+		"Despacito, quiero respirar tu cuello despacito, deja que te diga cosas al oído":             "es",
+		"Non, je ne regrette rien, ni le bien qu'on m'a fait, ni le mal, tout ça m'est bien égal":    "fr",
+		"Нас не догонят, только небо, только ветер, только радость впереди":                          "ru",
+		"Я так хочу до тебе, я так хочу бути з тобою, коли ніч приходить і світло згасає":            "uk",
+		"강남스타일 오빠는 강남스타일":                                                                            "ko",
+		"上を向いて歩こう 涙がこぼれないように":                                                                        "ja",
+		"Σ' αγαπώ μ' αρέσει να σε βλέπω":                                                             "el",
+	}
+	for text, expected := range cases {
+		if got, _ := DetectLanguage(text); got != expected {
+			t.Errorf("DetectLanguage(%q) = %q expected %q", text, got, expected)
+		}
+	}
+	if got, _ := DetectLanguage("la la la"); got != "" {
+		t.Errorf("language of the short text detected as %q", got)
+	}
+}
+
+func TestScriptLanguage(t *testing.T) {
+	cases := map[string]string{
+		"Gangnam Style (강남스타일)": "ko",
+		"Bohemian Rhapsody":     "",
+		"Кино":                  "",
+		"月光":                    "zh",
+	}
+	for title, expected := range cases {
+		if got := ScriptLanguage(title); got != expected {
+			t.Errorf("ScriptLanguage(%q) = %q expected %q", title, got, expected)
+		}
+	}
+}
+
+func TestChainPrefersExpectedLanguage(t *testing.T) {
+	english := "Is this the real life? Is this just fantasy? Caught in a landslide"
+	polish := "Czy to prawdziwe życie? Czy to tylko fantazja? Złapany w lawinę"
+	c := NewChain(
+		Provider{"english", &queryStub{result: &Result{Lyrics: english}}},
+		Provider{"polish", &queryStub{result: &Result{Lyrics: polish}}},
+	)
+	r, err := c.Fetch(context.Background(), Query{Title: "T", Language: "pl"})
+	if err != nil || r.Provider != "polish" || r.Language != "pl" {
+		t.Errorf("got %+v, %v", r, err)
+	}
+
+	// Lyrics in other language are better than none.
+	r, err = c.Fetch(context.Background(), Query{Title: "T", Language: "de"})
+	if err != nil || r.Provider != "english" || r.Language != "en" {
+		t.Errorf("got %+v, %v", r, err)
+	}
+
+	c.Race = true
+	r, err = c.Fetch(context.Background(), Query{Title: "T", Language: "pl"})
+	if err != nil || r.Provider != "polish" {
+		t.Errorf("race: got %+v, %v", r, err)
+	}
+}
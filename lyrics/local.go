@@ -0,0 +1,408 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLocalTemplates are the file name templates
+// LocalFetcher matches files with if none are set.
+var DefaultLocalTemplates = []string{
+	"{artist} - {title}.lrc",
+	"{artist} - {title}.txt",
+	"{artist}/{title}.lrc",
+	"{artist}/{title}.txt",
+	"{artist}/{album}/{title}.lrc",
+	"{artist}/{album}/{title}.txt",
+}
+
+// LocalFetcher looks the lyrics up in the local
+// directories of .lrc and .txt files, so it works
+// offline. It is best put first in the Chain, or in
+// front of the Cache with LocalFirst.
+//
+// Files are matched by their path relative to the
+// directory, with the Templates like "{artist} - {title}.lrc".
+// Placeholders are {artist}, {title} and {album},
+// the last one is ignored. Files with the artist and
+// title LRC tags are matched by the tags as well.
+// Names are compared folded, see Fold.
+//
+// Directories are indexed on the first lookup and
+// scanned again once files are added, removed or renamed
+// in them. Call Reindex to pick up the changed LRC tags.
+type LocalFetcher struct {
+	Dirs      []string
+	Templates []string
+
+	mu    sync.Mutex
+	index map[string]*localFiles
+	// modTimes are the modification times of the
+	// scanned directories, zero for the missing ones.
+	modTimes map[string]time.Time
+}
+
+// localFiles are the files found for the song.
+type localFiles struct {
+	lrc string
+	txt string
+}
+
+// NewLocalFetcher returns LocalFetcher looking in the dirs
+// with the DefaultLocalTemplates.
+func NewLocalFetcher(dirs ...string) *LocalFetcher {
+	return &LocalFetcher{Dirs: dirs, Templates: DefaultLocalTemplates}
+}
+
+// FetchLyrics implements Fetcher. Text of the
+// synced lyrics is returned if there is no .txt file.
+func (f *LocalFetcher) FetchLyrics(author, title string) (string, error) {
+	files := f.lookup(author, title)
+	if files == nil {
+		return "", ErrLyricsNotFound
+	}
+	if files.txt != "" {
+		data, err := ioutil.ReadFile(files.txt)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	l, err := readLRC(files.lrc)
+	if err != nil {
+		return "", err
+	}
+	return l.Text(), nil
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+// Only the .lrc files with timestamps are considered.
+func (f *LocalFetcher) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	files := f.lookup(author, title)
+	if files == nil || files.lrc == "" {
+		return nil, ErrLyricsNotFound
+	}
+	l, err := readLRC(files.lrc)
+	if err != nil {
+		return nil, err
+	}
+	if !l.Synced() {
+		return nil, ErrLyricsNotFound
+	}
+	return l, nil
+}
+
+// nameReplacer replaces the path separators
+// in the names filled into the templates.
+var nameReplacer = strings.NewReplacer("/", "-", "\\", "-")
+
+// Save writes the synced lyrics of the track into the
+// first of the Dirs, named after the first .lrc template,
+// and reindexes the directories. Returns path of the file.
+func (f *LocalFetcher) Save(q Query, l *Lyrics) (string, error) {
+	if len(f.Dirs) == 0 {
+		return "", errors.New("no local lyrics directory")
+	}
+	templates := f.Templates
+	if len(templates) == 0 {
+		templates = DefaultLocalTemplates
+	}
+	template := ""
+	for _, t := range templates {
+		if strings.HasSuffix(t, ".lrc") {
+			template = t
+			break
+		}
+	}
+	if template == "" {
+		return "", errors.New("no .lrc local lyrics template")
+	}
+	name := placeholderRe.ReplaceAllStringFunc(template, func(p string) string {
+		value := map[string]string{"{artist}": q.Artist(), "{title}": q.Title, "{album}": q.Album}[p]
+		return nameReplacer.Replace(value)
+	})
+	path := filepath.Join(f.Dirs[0], filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := ioutil.WriteFile(path, []byte(l.LRC()), 0644); err != nil {
+		return "", err
+	}
+	f.Reindex()
+	return path, nil
+}
+
+// Reindex scans the directories again.
+func (f *LocalFetcher) Reindex() {
+	f.reindex()
+}
+
+func (f *LocalFetcher) reindex() map[string]*localFiles {
+	index, modTimes := f.scan()
+	f.mu.Lock()
+	f.index, f.modTimes = index, modTimes
+	f.mu.Unlock()
+	return index
+}
+
+func (f *LocalFetcher) lookup(author, title string) *localFiles {
+	f.mu.Lock()
+	index, modTimes := f.index, f.modTimes
+	f.mu.Unlock()
+	if index == nil || dirsChanged(modTimes) {
+		index = f.reindex()
+	}
+	if files, ok := index[localKey(author, title)]; ok {
+		return files
+	}
+	// Files named by the title only.
+	return index[localKey("", title)]
+}
+
+func localKey(author, title string) string {
+	return Fold(author) + "|" + Fold(title)
+}
+
+// dirsChanged reports whether any of the directories
+// was modified since it was scanned.
+func dirsChanged(modTimes map[string]time.Time) bool {
+	for dir, scanned := range modTimes {
+		var modTime time.Time
+		if info, err := os.Stat(dir); err == nil {
+			modTime = info.ModTime()
+		}
+		if !modTime.Equal(scanned) {
+			return true
+		}
+	}
+	return false
+}
+
+// scan walks the directories and indexes the files
+// by their names and LRC tags. Unreadable files
+// and directories are skipped. Returns the index and
+// the modification times of the directories.
+func (f *LocalFetcher) scan() (map[string]*localFiles, map[string]time.Time) {
+	templates := f.Templates
+	if len(templates) == 0 {
+		templates = DefaultLocalTemplates
+	}
+	patterns := make([]*regexp.Regexp, 0, len(templates))
+	for _, t := range templates {
+		patterns = append(patterns, templateRegexp(t))
+	}
+	index := map[string]*localFiles{}
+	add := func(author, title, path string) {
+		if strings.TrimSpace(title) == "" {
+			return
+		}
+		key := localKey(author, title)
+		files, ok := index[key]
+		if !ok {
+			files = &localFiles{}
+			index[key] = files
+		}
+		// First found file wins, so the earlier
+		// directories take precedence.
+		if strings.EqualFold(filepath.Ext(path), ".lrc") {
+			if files.lrc == "" {
+				files.lrc = path
+			}
+		} else if files.txt == "" {
+			files.txt = path
+		}
+	}
+	modTimes := map[string]time.Time{}
+	for _, dir := range f.Dirs {
+		// Missing directories are noticed once created.
+		modTimes[dir] = time.Time{}
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				modTimes[path] = info.ModTime()
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext != ".lrc" && ext != ".txt" {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			for _, re := range patterns {
+				if m := re.FindStringSubmatch(rel); m != nil {
+					add(submatch(re, m, "artist"), submatch(re, m, "title"), path)
+				}
+			}
+			if ext == ".lrc" {
+				if l, err := readLRC(path); err == nil {
+					add(l.Tags[TagArtist], l.Tags[TagTitle], path)
+				}
+			}
+			return nil
+		})
+	}
+	return index, modTimes
+}
+
+var placeholderRe = regexp.MustCompile(`\{(artist|title|album)\}`)
+
+// templateRegexp turns the file name template into
+// the regexp capturing the artist and title.
+func templateRegexp(template string) *regexp.Regexp {
+	sb := strings.Builder{}
+	sb.WriteString(`(?i)^`)
+	last := 0
+	named := map[string]bool{}
+	for _, loc := range placeholderRe.FindAllStringSubmatchIndex(template, -1) {
+		sb.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		name := template[loc[2]:loc[3]]
+		// Only the first occurrence is captured,
+		// group names can't repeat.
+		if name == "album" || named[name] {
+			sb.WriteString(`[^/]+?`)
+		} else {
+			sb.WriteString(`(?P<` + name + `>[^/]+?)`)
+			named[name] = true
+		}
+		last = loc[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(template[last:]))
+	sb.WriteString(`$`)
+	return regexp.MustCompile(sb.String())
+}
+
+func submatch(re *regexp.Regexp, m []string, name string) string {
+	if i := re.SubexpIndex(name); i > 0 {
+		return m[i]
+	}
+	return ""
+}
+
+func readLRC(path string) (*Lyrics, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseLRC(file)
+}
+
+// LocalProvider is the provider name reported
+// for the lyrics found by LocalFirst.
+const LocalProvider = "local"
+
+// LocalFirst is a Fetcher and QueryFetcher decorator
+// looking the lyrics up in the Local files before asking
+// the wrapped Fetcher. Put in front of the Cache it finds
+// the files added after the lyrics were cached, not found
+// results too. Files are looked up with the CandidateQueries,
+// like with the Normalizer, and win over the fetched lyrics.
+type LocalFirst struct {
+	Local   *LocalFetcher
+	Fetcher Fetcher
+}
+
+// NewLocalFirst returns LocalFirst asking
+// the local files and then the Fetcher.
+func NewLocalFirst(local *LocalFetcher, f Fetcher) *LocalFirst {
+	return &LocalFirst{Local: local, Fetcher: f}
+}
+
+// found reports whether the local lookup found the
+// lyrics. Errors other than ErrLyricsNotFound are
+// logged and the Fetcher is asked then.
+func (l *LocalFirst) found(err error) bool {
+	if err != nil && KindOf(err) != NotFound {
+		log.Printf("Local lyrics lookup failed: %s", err)
+	}
+	return err == nil
+}
+
+// FetchLyrics implements Fetcher.
+func (l *LocalFirst) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := l.FetchTrackLyrics("", author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns
+// name of the provider which found them, if the
+// wrapped Fetcher reports it.
+func (l *LocalFirst) FetchLyricsFrom(author, title string) (string, string, error) {
+	return l.FetchTrackLyrics("", author, title)
+}
+
+// FetchTrackLyrics returns the lyrics of the local files
+// or fetches the lyrics of the track with the given id.
+func (l *LocalFirst) FetchTrackLyrics(id, author, title string) (string, string, error) {
+	if lyrics, err := NewNormalizer(l.Local).FetchLyrics(author, title); l.found(err) {
+		return lyrics, LocalProvider, nil
+	}
+	if tf, ok := l.Fetcher.(trackFetcher); ok {
+		return tf.FetchTrackLyrics(id, author, title)
+	}
+	if sf, ok := l.Fetcher.(sourceFetcher); ok {
+		return sf.FetchLyricsFrom(author, title)
+	}
+	lyrics, err := l.Fetcher.FetchLyrics(author, title)
+	return lyrics, "", err
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+func (l *LocalFirst) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := l.FetchTrackSyncedLyrics("", author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom is FetchLyricsFrom for
+// the synced lyrics.
+func (l *LocalFirst) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	return l.FetchTrackSyncedLyrics("", author, title)
+}
+
+// FetchTrackSyncedLyrics is FetchTrackLyrics for
+// the synced lyrics.
+func (l *LocalFirst) FetchTrackSyncedLyrics(id, author, title string) (*Lyrics, string, error) {
+	if lyrics, err := NewNormalizer(l.Local).FetchSyncedLyrics(author, title); l.found(err) {
+		return lyrics, LocalProvider, nil
+	}
+	if tf, ok := l.Fetcher.(trackFetcher); ok {
+		return tf.FetchTrackSyncedLyrics(id, author, title)
+	}
+	sf, ok := l.Fetcher.(SyncedFetcher)
+	if !ok {
+		return nil, "", ErrLyricsNotFound
+	}
+	if ssf, ok := l.Fetcher.(syncedSourceFetcher); ok {
+		return ssf.FetchSyncedLyricsFrom(author, title)
+	}
+	lyrics, err := sf.FetchSyncedLyrics(author, title)
+	return lyrics, "", err
+}
+
+// Fetch implements QueryFetcher. The wrapped Fetcher
+// is adapted with Adapt if it's not a QueryFetcher.
+func (l *LocalFirst) Fetch(ctx context.Context, q Query) (*Result, error) {
+	r, err := NewNormalizer(l.Local).Fetch(ctx, q)
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return nil, err
+	}
+	if l.found(err) {
+		r.Provider = LocalProvider
+		r.detectLanguage()
+		return r, nil
+	}
+	return Adapt(l.Fetcher).Fetch(ctx, q)
+}
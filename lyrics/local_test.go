@@ -0,0 +1,159 @@
+package lyrics
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLocalFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "lyricer-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLocalFetcherTemplates(t *testing.T) {
+	dir := writeLocalFiles(t, map[string]string{
+		"Sigur Rós - Hoppípolla.lrc":        "[00:01.00]Brosandi\n[00:05.00]Hendumst i hringi\n",
+		"Queen/Bohemian Rhapsody.txt":       "Is this the real life?\n",
+		"Queen/A Night/Love of My Life.lrc": "[00:02.00]Love of my life\n",
+		"notes.md":                          "not lyrics",
+	})
+	defer os.RemoveAll(dir)
+	f := NewLocalFetcher(dir)
+
+	lyrics, err := f.FetchSyncedLyrics("sigur ros", "HOPPIPOLLA")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(lyrics.Lines) != 2 || lyrics.Lines[1].Text != "Hendumst i hringi" {
+		t.Errorf("unexpected lyrics %+v", lyrics.Lines)
+	}
+	text, err := f.FetchLyrics("Queen", "Bohemian Rhapsody")
+	if err != nil || text != "Is this the real life?" {
+		t.Errorf("got %q, %v", text, err)
+	}
+	if _, err := f.FetchLyrics("Queen", "Love of My Life"); err != nil {
+		t.Errorf("album template: unexpected error %s", err)
+	}
+	if _, err := f.FetchSyncedLyrics("Queen", "Bohemian Rhapsody"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound for plain text file got %v", err)
+	}
+	if _, err := f.FetchLyrics("Queen", "Innuendo"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestLocalFetcherTags(t *testing.T) {
+	dir := writeLocalFiles(t, map[string]string{
+		"track01.lrc": "[ar:Björk]\n[ti:Jóga]\n[00:10.00]All these accidents\n",
+	})
+	defer os.RemoveAll(dir)
+	f := NewLocalFetcher(dir)
+
+	lyrics, err := f.FetchSyncedLyrics("Bjork", "Joga")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if lyrics.Lines[0].Text != "All these accidents" {
+		t.Errorf("unexpected lyrics %+v", lyrics.Lines)
+	}
+}
+
+func TestLocalFetcherCustomTemplate(t *testing.T) {
+	dir := writeLocalFiles(t, map[string]string{
+		"lyrics/Hoppípolla [Sigur Rós].lrc": "[00:01.00]Brosandi\n",
+	})
+	defer os.RemoveAll(dir)
+	f := &LocalFetcher{
+		Dirs:      []string{dir},
+		Templates: []string{"lyrics/{title} [{artist}].lrc"},
+	}
+	if _, err := f.FetchSyncedLyrics("Sigur Rós", "Hoppípolla"); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	os.Remove(filepath.Join(dir, "lyrics", "Hoppípolla [Sigur Rós].lrc"))
+	f.Reindex()
+	if _, err := f.FetchSyncedLyrics("Sigur Rós", "Hoppípolla"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound after reindex got %v", err)
+	}
+}
+
+func TestLocalFetcherSave(t *testing.T) {
+	dir := writeLocalFiles(t, map[string]string{})
+	defer os.RemoveAll(dir)
+	f := &LocalFetcher{
+		Dirs:      []string{dir},
+		Templates: []string{"{artist}/{title}.txt", "{artist}/{title}.lrc"},
+	}
+	l, _ := ParseLRC(strings.NewReader("[00:01.00]Brosandi\n"))
+	path, err := f.Save(Query{Artists: []string{"AC/DC"}, Title: "Hoppípolla"}, l)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "AC-DC", "Hoppípolla.lrc"); path != expected {
+		t.Errorf("saved to %s expected %s", path, expected)
+	}
+	if synced, err := f.FetchSyncedLyrics("AC-DC", "Hoppípolla"); err != nil || synced.Text() != "Brosandi" {
+		t.Errorf("saved lyrics not found, got %v", err)
+	}
+}
+
+func TestLocalFirst(t *testing.T) {
+	dir := writeLocalFiles(t, map[string]string{})
+	defer os.RemoveAll(dir)
+	local := NewLocalFetcher(dir)
+	stub := &queryStub{err: &Error{Kind: NotFound, Provider: "lrclib"}}
+	f := NewLocalFirst(local, NewCache(stub, ""))
+	q := Query{Artists: []string{"Queen"}, Title: "Bohemian Rhapsody - Remastered 2011", SpotifyID: "id"}
+
+	if _, err := f.Fetch(context.Background(), q); KindOf(err) != NotFound {
+		t.Fatalf("expected NotFound got %v", err)
+	}
+	path := filepath.Join(dir, "Queen - Bohemian Rhapsody.lrc")
+	if err := ioutil.WriteFile(path, []byte("[00:01.00]Is this the real life?\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := f.Fetch(context.Background(), q)
+	if err != nil || r.Provider != LocalProvider || r.Synced == nil || stub.calls != 1 {
+		t.Errorf("added file hidden by the cache, got %+v, %v after %d calls", r, err, stub.calls)
+	}
+	synced, provider, err := f.FetchTrackSyncedLyrics("id", "Queen", "Bohemian Rhapsody")
+	if err != nil || provider != LocalProvider || synced.Lines[0].Text != "Is this the real life?" {
+		t.Errorf("got %+v from %q, %v", synced, provider, err)
+	}
+	if lyrics, provider, _ := f.FetchLyricsFrom("Queen", "Bohemian Rhapsody"); lyrics != "Is this the real life?" || provider != LocalProvider {
+		t.Errorf("got %q from %q", lyrics, provider)
+	}
+}
+
+func TestLocalFetcherRescansChangedDirs(t *testing.T) {
+	dir := writeLocalFiles(t, map[string]string{"Queen/Love of My Life.txt": "Love of my life\n"})
+	defer os.RemoveAll(dir)
+	f := NewLocalFetcher(dir)
+	if _, err := f.FetchLyrics("Queen", "Bohemian Rhapsody"); err != ErrLyricsNotFound {
+		t.Fatalf("expected ErrLyricsNotFound got %v", err)
+	}
+	path := filepath.Join(dir, "Queen", "Bohemian Rhapsody.txt")
+	if err := ioutil.WriteFile(path, []byte("Is this the real life?\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if lyrics, err := f.FetchLyrics("Queen", "Bohemian Rhapsody"); err != nil || lyrics != "Is this the real life?" {
+		t.Errorf("added file not found, got %q, %v", lyrics, err)
+	}
+}
@@ -0,0 +1,386 @@
+package lyrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncedFetcher should provide time-synced lyrics
+// given the songs artist and it's title.
+// Providers that can return timed lyrics
+// should implement it next to the Fetcher.
+type SyncedFetcher interface {
+	FetchSyncedLyrics(author, title string) (*Lyrics, error)
+}
+
+// NoTime is the Time of the lines and words
+// which were not synced.
+const NoTime time.Duration = -1
+
+// Word is a single word of the line
+// with its own timestamp, as in the enhanced LRC.
+type Word struct {
+	Time time.Duration
+	Text string
+}
+
+// Line is a single line of the lyrics.
+type Line struct {
+	// Time is the moment the line starts
+	// to be sung, NoTime if unknown.
+	Time time.Duration
+	Text string
+	// Words are the timed words of the line,
+	// empty if the lyrics have no word-level timing.
+	Words []Word
+	// Translation of the line, set by Translate.
+	Translation string
+	// Romanized line if it's not in the Latin
+	// script, set by Romanize.
+	Romanized string
+}
+
+// Lyrics are the structured lyrics of a song.
+// If the lyrics are synced lines are ordered by time.
+type Lyrics struct {
+	// Tags are the LRC metadata tags like
+	// "ar", "ti" or "length" with their values.
+	Tags map[string]string
+	// Offset is the value of the [offset] tag.
+	// Positive offset makes the lines show up sooner.
+	Offset time.Duration
+	Lines  []Line
+}
+
+// Tags defined by the LRC format.
+const (
+	TagArtist = "ar"
+	TagTitle  = "ti"
+	TagAlbum  = "al"
+	TagAuthor = "au"
+	TagLength = "length"
+	TagBy     = "by"
+	TagOffset = "offset"
+)
+
+// tagOrder is the order the well known tags
+// are serialized in, other tags follow sorted.
+var tagOrder = []string{TagArtist, TagTitle, TagAlbum, TagAuthor, TagLength, TagBy, TagOffset, "re", "ve"}
+
+// Synced reports whether the lyrics have timed lines.
+func (l *Lyrics) Synced() bool {
+	for _, line := range l.Lines {
+		if line.Time != NoTime {
+			return true
+		}
+	}
+	return false
+}
+
+// Length returns the song length from the [length]
+// tag, zero if it's missing or invalid.
+func (l *Lyrics) Length() time.Duration {
+	d, ok := parseTimestamp(l.Tags[TagLength])
+	if !ok {
+		return 0
+	}
+	return d
+}
+
+// SetLength sets the [length] tag to the song length.
+func (l *Lyrics) SetLength(d time.Duration) {
+	if l.Tags == nil {
+		l.Tags = map[string]string{}
+	}
+	l.Tags[TagLength] = formatTimestamp(d)
+}
+
+// Text returns the lyrics as plain text,
+// one line per line.
+func (l *Lyrics) Text() string {
+	lines := make([]string, len(l.Lines))
+	for i, line := range l.Lines {
+		lines[i] = line.Text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LineAt returns index of the line being sung
+// at the given song progress, taking Offset into account.
+// Returns -1 before the first line or if the
+// lyrics are not synced.
+func (l *Lyrics) LineAt(progress time.Duration) int {
+	progress += l.Offset
+	i := sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Time > progress
+	})
+	i--
+	if i < 0 || l.Lines[i].Time == NoTime {
+		return -1
+	}
+	return i
+}
+
+// PlainLyrics returns unsynced Lyrics made
+// out of the plain text.
+func PlainLyrics(text string) *Lyrics {
+	l := &Lyrics{Tags: map[string]string{}}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		l.Lines = append(l.Lines, Line{Time: NoTime, Text: strings.TrimRight(line, "\r")})
+	}
+	return l
+}
+
+var (
+	// lineTimeRe matches the leading [mm:ss.xx] timestamps.
+	lineTimeRe = regexp.MustCompile(`^\[(\d+:\d{1,2}(?:[.:]\d{1,3})?)\]`)
+	// tagRe matches the [key:value] metadata tags.
+	tagRe = regexp.MustCompile(`^\[([A-Za-z]+):(.*)\]$`)
+	// wordTimeRe matches the enhanced LRC <mm:ss.xx> word timestamps.
+	wordTimeRe = regexp.MustCompile(`<(\d+:\d{1,2}(?:[.:]\d{1,3})?)>`)
+)
+
+// ParseLRC parses the standard or enhanced LRC lyrics.
+//
+// Parser is lenient: lines with several timestamps are
+// repeated for each of them, unknown tags are kept in Tags,
+// untimed text lines in the synced lyrics take the time of
+// the line before them and malformed timestamps are treated
+// as text. If there are no timestamps at all the lyrics
+// are returned unsynced with all lines set to NoTime.
+//
+// Returned error comes only from reading r.
+func ParseLRC(r io.Reader) (*Lyrics, error) {
+	l := &Lyrics{Tags: map[string]string{}}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	first := true
+	for scanner.Scan() {
+		raw := strings.TrimSpace(scanner.Text())
+		if first {
+			raw = strings.TrimPrefix(raw, "\ufeff")
+			first = false
+		}
+		l.parseLine(raw)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	l.finish()
+	return l, nil
+}
+
+func (l *Lyrics) parseLine(raw string) {
+	var times []time.Duration
+	rest := raw
+	for {
+		m := lineTimeRe.FindStringSubmatch(rest)
+		if m == nil {
+			break
+		}
+		t, ok := parseTimestamp(m[1])
+		if !ok {
+			break
+		}
+		times = append(times, t)
+		rest = rest[len(m[0]):]
+	}
+	if len(times) == 0 {
+		if m := tagRe.FindStringSubmatch(raw); m != nil {
+			l.setTag(strings.ToLower(m[1]), strings.TrimSpace(m[2]))
+			return
+		}
+		if strings.HasPrefix(raw, "#") {
+			// Comment line.
+			return
+		}
+		l.Lines = append(l.Lines, Line{Time: NoTime, Text: raw})
+		return
+	}
+	text, words := parseWords(rest)
+	for _, t := range times {
+		l.Lines = append(l.Lines, Line{Time: t, Text: text, Words: words})
+	}
+}
+
+func (l *Lyrics) setTag(key, value string) {
+	l.Tags[key] = value
+	if key == TagOffset {
+		ms, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+		if err == nil {
+			l.Offset = time.Millisecond * time.Duration(ms)
+		}
+	}
+}
+
+// parseWords splits the enhanced LRC line into its
+// plain text and the timed words.
+func parseWords(s string) (string, []Word) {
+	locs := wordTimeRe.FindAllStringSubmatchIndex(s, -1)
+	if len(locs) == 0 {
+		return strings.TrimSpace(s), nil
+	}
+	words := []Word{}
+	text := strings.Builder{}
+	text.WriteString(s[:locs[0][0]])
+	for i, loc := range locs {
+		end := len(s)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		t, _ := parseTimestamp(s[loc[2]:loc[3]])
+		word := s[loc[1]:end]
+		text.WriteString(word)
+		if strings.TrimSpace(word) != "" {
+			words = append(words, Word{Time: t, Text: strings.TrimSpace(word)})
+		}
+	}
+	return strings.TrimSpace(strings.Join(strings.Fields(text.String()), " ")), words
+}
+
+// finish orders the lines by time if the lyrics are synced.
+func (l *Lyrics) finish() {
+	// Trailing and leading blank lines are noise.
+	for len(l.Lines) > 0 && l.Lines[0].Time == NoTime && l.Lines[0].Text == "" {
+		l.Lines = l.Lines[1:]
+	}
+	for len(l.Lines) > 0 && l.Lines[len(l.Lines)-1].Time == NoTime && l.Lines[len(l.Lines)-1].Text == "" {
+		l.Lines = l.Lines[:len(l.Lines)-1]
+	}
+	if !l.Synced() {
+		return
+	}
+	lines := l.Lines[:0]
+	prev := time.Duration(0)
+	for _, line := range l.Lines {
+		if line.Time == NoTime {
+			// Blank lines between verses carry no
+			// information in the synced lyrics.
+			if line.Text == "" {
+				continue
+			}
+			line.Time = prev
+		}
+		prev = line.Time
+		lines = append(lines, line)
+	}
+	l.Lines = lines
+	sort.SliceStable(l.Lines, func(i, j int) bool {
+		return l.Lines[i].Time < l.Lines[j].Time
+	})
+}
+
+// parseTimestamp parses mm:ss, mm:ss.x, mm:ss.xx,
+// mm:ss.xxx and mm:ss:xx timestamps.
+func parseTimestamp(s string) (time.Duration, bool) {
+	colon := strings.Index(s, ":")
+	if colon < 0 {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(s[:colon])
+	if err != nil {
+		return 0, false
+	}
+	rest := s[colon+1:]
+	fraction := ""
+	if i := strings.IndexAny(rest, ".:"); i >= 0 {
+		rest, fraction = rest[:i], rest[i+1:]
+	}
+	seconds, err := strconv.Atoi(rest)
+	if err != nil || seconds >= 60 {
+		return 0, false
+	}
+	d := time.Minute*time.Duration(minutes) + time.Second*time.Duration(seconds)
+	if fraction != "" {
+		// Milliseconds are the finest LRC precision.
+		if len(fraction) > 3 {
+			return 0, false
+		}
+		f, err := strconv.Atoi(fraction)
+		if err != nil {
+			return 0, false
+		}
+		// .5 is half, .05 is 5 hundredths and .005 is 5 ms.
+		for i := len(fraction); i < 3; i++ {
+			f *= 10
+		}
+		d += time.Millisecond * time.Duration(f)
+	}
+	return d, true
+}
+
+// leadingText returns the text of the line
+// before its first timed word.
+func (line Line) leadingText() string {
+	texts := make([]string, len(line.Words))
+	for i, w := range line.Words {
+		texts[i] = w.Text
+	}
+	words := strings.Join(strings.Fields(strings.Join(texts, " ")), " ")
+	if !strings.HasSuffix(line.Text, words) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimSuffix(line.Text, words))
+}
+
+// formatTimestamp formats the duration as mm:ss.xx.
+func formatTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := (d + 5*time.Millisecond) / (10 * time.Millisecond)
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+// LRC serializes the lyrics to the LRC format.
+// Words are written as the enhanced LRC if present.
+// Unsynced lines are written without timestamps.
+func (l *Lyrics) LRC() string {
+	sb := strings.Builder{}
+	written := map[string]bool{}
+	// Offset tag is always written from the Offset field.
+	written[TagOffset] = true
+	for _, key := range tagOrder {
+		if value, ok := l.Tags[key]; ok && !written[key] {
+			fmt.Fprintf(&sb, "[%s:%s]\n", key, value)
+			written[key] = true
+		}
+		if key == TagOffset && l.Offset != 0 {
+			fmt.Fprintf(&sb, "[%s:%+d]\n", TagOffset, l.Offset.Milliseconds())
+		}
+	}
+	rest := []string{}
+	for key := range l.Tags {
+		if !written[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		fmt.Fprintf(&sb, "[%s:%s]\n", key, l.Tags[key])
+	}
+	for _, line := range l.Lines {
+		if line.Time != NoTime {
+			fmt.Fprintf(&sb, "[%s]", formatTimestamp(line.Time))
+		}
+		if len(line.Words) == 0 {
+			sb.WriteString(line.Text)
+		} else if lead := line.leadingText(); lead != "" {
+			sb.WriteString(lead + " ")
+		}
+		for i, w := range line.Words {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			fmt.Fprintf(&sb, "<%s>%s", formatTimestamp(w.Time), w.Text)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
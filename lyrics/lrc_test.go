@@ -0,0 +1,119 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration {
+	return time.Millisecond * time.Duration(n)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	cases := []struct {
+		in  string
+		out time.Duration
+		ok  bool
+	}{
+		{"00:12", ms(12000), true},
+		{"00:12.5", ms(12500), true},
+		{"00:12.34", ms(12340), true},
+		{"00:12.345", ms(12345), true},
+		{"00:01.1234", 0, false},
+		{"01:02:03", ms(62030), true},
+		{"100:00.00", 100 * time.Minute, true},
+		{"00:60.00", 0, false},
+		{"0012", 0, false},
+		{"aa:12", 0, false},
+	}
+	for _, c := range cases {
+		out, ok := parseTimestamp(c.in)
+		if out != c.out || ok != c.ok {
+			t.Errorf("parseTimestamp(%q) = %s, %v expected %s, %v", c.in, out, ok, c.out, c.ok)
+		}
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	in := "\ufeff[ar:Artist]\n" +
+		"[ti: Title ]\n" +
+		"[offset:+250]\n" +
+		"[length:03:20]\n" +
+		"# comment\n" +
+		"[00:20.00]Second\n" +
+		"[00:10.00][00:30.00]Chorus\n" +
+		"\n" +
+		"untimed after chorus\n" +
+		"[00:40.00]<00:40.00>Word <00:40.50>by <00:41.00>word\n"
+	l, err := ParseLRC(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Tags[TagArtist] != "Artist" || l.Tags[TagTitle] != "Title" {
+		t.Errorf("Wrong tags %v", l.Tags)
+	}
+	if l.Offset != ms(250) || l.Length() != 200*time.Second {
+		t.Errorf("Wrong offset %s or length %s", l.Offset, l.Length())
+	}
+	expected := []Line{
+		{Time: ms(10000), Text: "Chorus"},
+		{Time: ms(20000), Text: "Second"},
+		{Time: ms(30000), Text: "Chorus"},
+		{Time: ms(30000), Text: "untimed after chorus"},
+		{Time: ms(40000), Text: "Word by word"},
+	}
+	if len(l.Lines) != len(expected) {
+		t.Fatalf("Got %d lines expected %d: %+v", len(l.Lines), len(expected), l.Lines)
+	}
+	for i, line := range l.Lines {
+		if line.Time != expected[i].Time || line.Text != expected[i].Text {
+			t.Errorf("Line %d is %+v expected %+v", i, line, expected[i])
+		}
+	}
+	words := l.Lines[4].Words
+	if len(words) != 3 || words[1].Time != ms(40500) || words[1].Text != "by" {
+		t.Errorf("Wrong words %+v", words)
+	}
+}
+
+func TestParsePlainText(t *testing.T) {
+	l, err := ParseLRC(strings.NewReader("\nfirst\n\nsecond [not a tag\n\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Synced() {
+		t.Errorf("Plain text parsed as synced")
+	}
+	if l.Text() != "first\n\nsecond [not a tag" {
+		t.Errorf("Wrong text %q", l.Text())
+	}
+}
+
+func TestLineAt(t *testing.T) {
+	l, _ := ParseLRC(strings.NewReader("[00:01.00]a\n[00:02.00]b\n[00:03.00]c"))
+	cases := map[time.Duration]int{0: -1, ms(1000): 0, ms(2500): 1, time.Hour: 2}
+	for progress, line := range cases {
+		if got := l.LineAt(progress); got != line {
+			t.Errorf("LineAt(%s) = %d expected %d", progress, got, line)
+		}
+	}
+	l.Offset = ms(600)
+	if got := l.LineAt(ms(1500)); got != 1 {
+		t.Errorf("LineAt with offset = %d expected 1", got)
+	}
+}
+
+func TestLRCRoundTrip(t *testing.T) {
+	in := "[ar:A]\n[ti:T]\n[offset:-100]\n[x:custom]\n" +
+		"[00:01.50]first\n" +
+		"[00:02.00]<00:02.00>enhanced <00:02.25>line\n" +
+		"[00:03.00]oh <00:03.50>timed <00:03.75>words\n"
+	l, err := ParseLRC(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := l.LRC(); out != in {
+		t.Errorf("Serialized:\n%s\nexpected:\n%s", out, in)
+	}
+}
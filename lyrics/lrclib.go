@@ -0,0 +1,348 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LRCLibURL is the default LRCLibFetcher base url.
+const LRCLibURL = "https://lrclib.net"
+
+// LRCLibFetcher is a QueryFetcher, Fetcher and SyncedFetcher
+// speaking the LRCLIB api. Its zero value asks
+// the public lrclib.net instance, set the BaseURL
+// to use a self-hosted one.
+//
+// The song is got by its exact artist, title, album
+// and duration first,
+// if that fails the search results are matched
+// with the Matcher.
+type LRCLibFetcher struct {
+	// BaseURL of the instance, LRCLibURL if empty.
+	BaseURL string
+	// Client makes the requests,
+	// http.DefaultClient if nil.
+	Client *http.Client
+	// Matcher picks the search result,
+	// DefaultMatcher if nil.
+	Matcher *Matcher
+	// Versions makes Fetch return the other search
+	// results matching the song, like the live or
+	// album versions, as the Result Versions.
+	// It costs the search request.
+	Versions bool
+}
+
+// LRCLibTrack is the lyrics record of the LRCLIB api.
+type LRCLibTrack struct {
+	ID           int     `json:"id"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"`
+	Instrumental bool    `json:"instrumental"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
+
+// Synced parses the synced lyrics of the track
+// filling the missing tags from the record.
+// Returns nil if the track has none.
+func (t *LRCLibTrack) Synced() (*Lyrics, error) {
+	if t.SyncedLyrics == "" {
+		return nil, nil
+	}
+	l, err := ParseLRC(strings.NewReader(t.SyncedLyrics))
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]string{
+		TagArtist: t.ArtistName,
+		TagTitle:  t.TrackName,
+		TagAlbum:  t.AlbumName,
+	}
+	if t.Duration > 0 {
+		d := time.Duration(t.Duration * float64(time.Second))
+		tags[TagLength] = formatTimestamp(d)
+	}
+	for key, value := range tags {
+		if _, ok := l.Tags[key]; !ok && value != "" {
+			l.Tags[key] = value
+		}
+	}
+	return l, nil
+}
+
+func (t *LRCLibTrack) hit() Hit {
+	return Hit{
+		Artist:   t.ArtistName,
+		Title:    t.TrackName,
+		Album:    t.AlbumName,
+		Duration: time.Duration(t.Duration * float64(time.Second)),
+		Ref:      strconv.Itoa(t.ID),
+	}
+}
+
+// lrclibName is the provider name in the errors and results.
+const lrclibName = "lrclib"
+
+// Fetch implements QueryFetcher, preferring the synced lyrics.
+// The exact record is got once and the search results too,
+// only if the record lacks the synced lyrics or the Versions
+// are asked for, then the lyrics are chosen out of them.
+func (f LRCLibFetcher) Fetch(ctx context.Context, q Query) (*Result, error) {
+	song := q.song()
+	exact, err := f.Get(ctx, song)
+	if err != nil && KindOf(err) != NotFound {
+		return nil, queryError(lrclibName, err)
+	}
+	if exact != nil && exact.Instrumental {
+		return nil, &Error{Kind: Instrumental, Provider: lrclibName}
+	}
+	var found []LRCLibTrack
+	if exact == nil || !exact.hasLyrics(true) || f.Versions {
+		found, err = f.Search(ctx, song.Author, song.Title)
+		if err != nil && (exact == nil || !exact.hasLyrics(false)) {
+			return nil, queryError(lrclibName, err)
+		}
+		if err != nil {
+			log.Printf("Couldn't search lrclib for the lyrics: %s", err)
+		}
+	}
+	t := exact
+	if t == nil || !t.hasLyrics(true) {
+		if t, err = f.choose(song, exact, found); err != nil {
+			return nil, queryError(lrclibName, err)
+		}
+	}
+	r, err := f.result(song, t)
+	if err != nil {
+		return nil, queryError(lrclibName, err)
+	}
+	if f.Versions {
+		r.Versions = f.versions(song, found, t.ID)
+	}
+	return r, nil
+}
+
+// choose returns the track with the lyrics of the song
+// out of the exact record without the synced lyrics and
+// the search results. The synced lyrics found by the search
+// come first, then the plain ones of the exact record.
+func (f LRCLibFetcher) choose(song SongInfo, exact *LRCLibTrack, found []LRCLibTrack) (*LRCLibTrack, error) {
+	if t, err := f.best(song, found, true); err == nil {
+		return t, nil
+	}
+	if exact != nil && exact.hasLyrics(false) {
+		return exact, nil
+	}
+	return f.best(song, found, false)
+}
+
+// result returns the Result of the track
+// with the confidence it's of the song.
+func (f LRCLibFetcher) result(song SongInfo, t *LRCLibTrack) (*Result, error) {
+	synced, err := t.Synced()
+	if err != nil {
+		return nil, err
+	}
+	r := newResult(lrclibName, t.PlainLyrics, synced)
+	r.URL = f.baseURL() + "/api/get/" + strconv.Itoa(t.ID)
+	r.Confidence = Score(song, t.hit())
+	return r, nil
+}
+
+// versions returns the Results of the search results with
+// the lyrics, other than the found track, matching the
+// song with at least the Matcher threshold, best first.
+func (f LRCLibFetcher) versions(song SongInfo, tracks []LRCLibTrack, found int) []*Result {
+	m := f.matcher()
+	versions := []*Result{}
+	for i := range tracks {
+		t := &tracks[i]
+		if t.ID == found || !t.hasLyrics(false) {
+			continue
+		}
+		r, err := f.result(song, t)
+		if err != nil || r.Confidence < m.Threshold {
+			continue
+		}
+		versions = append(versions, r)
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Confidence > versions[j].Confidence
+	})
+	return versions
+}
+
+// FetchLyrics implements Fetcher. Text of the synced
+// lyrics is returned if there are no plain ones.
+func (f LRCLibFetcher) FetchLyrics(author, title string) (string, error) {
+	t, err := f.Find(context.Background(), SongInfo{Author: author, Title: title}, false)
+	if err != nil {
+		return "", fetcherError(err)
+	}
+	if t.PlainLyrics != "" {
+		return strings.TrimSpace(t.PlainLyrics), nil
+	}
+	l, err := t.Synced()
+	if err != nil {
+		return "", err
+	}
+	return l.Text(), nil
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+func (f LRCLibFetcher) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	t, err := f.Find(context.Background(), SongInfo{Author: author, Title: title}, true)
+	if err != nil {
+		return nil, fetcherError(err)
+	}
+	return t.Synced()
+}
+
+// Find returns the track record of the song with the
+// lyrics, synced ones if synced is set. Album and duration
+// of the song are used if they are known. Tracks without
+// lyrics are reported as the NotFound *Error and
+// the instrumental ones as the Instrumental *Error.
+func (f LRCLibFetcher) Find(ctx context.Context, song SongInfo, synced bool) (*LRCLibTrack, error) {
+	t, err := f.Get(ctx, song)
+	if err == nil && t.Instrumental {
+		return nil, &Error{Kind: Instrumental, Provider: lrclibName}
+	}
+	if err == nil && t.hasLyrics(synced) {
+		return t, nil
+	}
+	if err != nil && KindOf(err) != NotFound {
+		return nil, err
+	}
+	// The exact record can be missing or lack
+	// the lyrics while the other ones have them.
+	return f.searchBest(ctx, song, synced)
+}
+
+func (t *LRCLibTrack) hasLyrics(synced bool) bool {
+	if t.Instrumental {
+		return false
+	}
+	if synced {
+		return t.SyncedLyrics != ""
+	}
+	return t.PlainLyrics != "" || t.SyncedLyrics != ""
+}
+
+// Get returns the track record exactly matching the song.
+func (f LRCLibFetcher) Get(ctx context.Context, song SongInfo) (*LRCLibTrack, error) {
+	query := url.Values{}
+	query.Set("artist_name", song.Author)
+	query.Set("track_name", song.Title)
+	if song.Album != "" {
+		query.Set("album_name", song.Album)
+	}
+	if song.Duration > 0 {
+		query.Set("duration", strconv.Itoa(int(song.Duration.Round(time.Second).Seconds())))
+	}
+	var t LRCLibTrack
+	if err := f.get(ctx, "/api/get", query, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Search returns the track records
+// found for the artist and title.
+func (f LRCLibFetcher) Search(ctx context.Context, author, title string) ([]LRCLibTrack, error) {
+	query := url.Values{}
+	query.Set("artist_name", author)
+	query.Set("track_name", title)
+	tracks := []LRCLibTrack{}
+	if err := f.get(ctx, "/api/search", query, &tracks); err != nil {
+		return nil, err
+	}
+	return tracks, nil
+}
+
+// searchBest returns the search result best matching
+// the song out of the ones with the lyrics.
+func (f LRCLibFetcher) searchBest(ctx context.Context, song SongInfo, synced bool) (*LRCLibTrack, error) {
+	found, err := f.Search(ctx, song.Author, song.Title)
+	if err != nil {
+		return nil, err
+	}
+	return f.best(song, found, synced)
+}
+
+// best returns the track best matching the song
+// out of the ones with the lyrics.
+func (f LRCLibFetcher) best(song SongInfo, found []LRCLibTrack, synced bool) (*LRCLibTrack, error) {
+	tracks := []LRCLibTrack{}
+	hits := []Hit{}
+	for _, t := range found {
+		if t.hasLyrics(synced) {
+			tracks = append(tracks, t)
+			hits = append(hits, t.hit())
+		}
+	}
+	best, _, err := f.matcher().Best(song, hits)
+	if err != nil {
+		return nil, &Error{Kind: NotFound, Provider: lrclibName, Err: err}
+	}
+	for i := range tracks {
+		if strconv.Itoa(tracks[i].ID) == best.Ref {
+			return &tracks[i], nil
+		}
+	}
+	return nil, &Error{Kind: NotFound, Provider: lrclibName}
+}
+
+func (f LRCLibFetcher) matcher() Matcher {
+	if f.Matcher != nil {
+		return *f.Matcher
+	}
+	return DefaultMatcher
+}
+
+func (f LRCLibFetcher) baseURL() string {
+	if f.BaseURL != "" {
+		return strings.TrimRight(f.BaseURL, "/")
+	}
+	return LRCLibURL
+}
+
+// get decodes the json response of the api into v.
+// Failures are reported as the *Error.
+func (f LRCLibFetcher) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	req, err := http.NewRequest("GET", f.baseURL()+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	// LRCLIB asks the clients to identify themselves.
+	req.Header.Set("User-Agent", "Lyricer (https://github.com/gala377/LyricerSpotify)")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &Error{Kind: ProviderDown, Provider: lrclibName, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(lrclibName, resp)
+	}
+	if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return &Error{Kind: ProviderDown, Provider: lrclibName, Err: err}
+	}
+	return nil
+}
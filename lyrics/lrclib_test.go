@@ -0,0 +1,161 @@
+package lyrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// lrclibServer serves the saved get response for the
+// "I Want to Live" and search results for any query.
+func lrclibServer(queries *[]url.Values) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if queries != nil {
+			*queries = append(*queries, r.URL.Query())
+		}
+		switch r.URL.Path {
+		case "/api/get":
+			if r.URL.Query().Get("track_name") != "I Want to Live" {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":404,"name":"TrackNotFound","message":"Failed to find specified track"}`))
+				return
+			}
+			http.ServeFile(w, r, filepath.Join("testdata", "lrclib_get.json"))
+		case "/api/search":
+			http.ServeFile(w, r, filepath.Join("testdata", "lrclib_search.json"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestLRCLibGet(t *testing.T) {
+	queries := []url.Values{}
+	server := lrclibServer(&queries)
+	defer server.Close()
+	f := LRCLibFetcher{BaseURL: server.URL}
+
+	song := SongInfo{
+		Author:   "Borislav Slavov",
+		Title:    "I Want to Live",
+		Album:    "Baldur's Gate 3 (Original Game Soundtrack)",
+		Duration: 233400 * time.Millisecond,
+	}
+	track, err := f.Find(context.Background(), song, true)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	q := queries[0]
+	if q.Get("album_name") != song.Album || q.Get("duration") != "233" {
+		t.Errorf("unexpected query %v", q)
+	}
+	lyrics, err := track.Synced()
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if len(lyrics.Lines) != 2 || lyrics.Lines[1].Time != 20410*time.Millisecond {
+		t.Errorf("unexpected lines %+v", lyrics.Lines)
+	}
+	if lyrics.Length() != 233*time.Second || lyrics.Tags[TagArtist] != "Borislav Slavov" {
+		t.Errorf("tags not filled from the record %v", lyrics.Tags)
+	}
+}
+
+func TestLRCLibFetchLyrics(t *testing.T) {
+	server := lrclibServer(nil)
+	defer server.Close()
+	f := LRCLibFetcher{BaseURL: server.URL}
+
+	lyrics, err := f.FetchLyrics("Borislav Slavov", "I Want to Live")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if lyrics != "I feel your breath upon my neck\nA soft caress as cold as death" {
+		t.Errorf("unexpected lyrics %q", lyrics)
+	}
+}
+
+func TestLRCLibSearchFallback(t *testing.T) {
+	server := lrclibServer(nil)
+	defer server.Close()
+	f := LRCLibFetcher{BaseURL: server.URL}
+
+	// Live version comes first but has no synced lyrics.
+	lyrics, err := f.FetchSyncedLyrics("Sigur Ros", "Hoppipolla")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if lyrics.Tags[TagAlbum] != "Takk..." || lyrics.Lines[0].Time != 42*time.Second {
+		t.Errorf("unexpected lyrics %+v", lyrics)
+	}
+	// Instrumental tracks have no lyrics.
+	if _, err := f.FetchLyrics("Sigur Ros", "Glosoli"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestLRCLibVersions(t *testing.T) {
+	server := lrclibServer(nil)
+	defer server.Close()
+	f := LRCLibFetcher{BaseURL: server.URL, Versions: true}
+
+	r, err := f.Fetch(context.Background(), Query{Artists: []string{"Sigur Ros"}, Title: "Hoppipolla"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if r.Synced == nil || r.Confidence < DefaultMatchThreshold {
+		t.Errorf("expected synced album version got %+v", r)
+	}
+	if len(r.Versions) != 1 || r.Versions[0].URL != server.URL+"/api/get/101" || r.Versions[0].Synced != nil {
+		t.Fatalf("expected the live version got %+v", r.Versions)
+	}
+	if r.Versions[0].Confidence <= 0 || r.Versions[0].Confidence > r.Confidence {
+		t.Errorf("live version confidence %.2f, album one %.2f", r.Versions[0].Confidence, r.Confidence)
+	}
+}
+
+func TestLRCLibFetchRequests(t *testing.T) {
+	queries := []url.Values{}
+	server := lrclibServer(&queries)
+	defer server.Close()
+	f := LRCLibFetcher{BaseURL: server.URL, Versions: true}
+
+	// Get and a single search for the lyrics and their versions.
+	r, err := f.Fetch(context.Background(), Query{Artists: []string{"Sigur Ros"}, Title: "Hoppipolla"})
+	if err != nil || r.Synced == nil || len(r.Versions) != 1 {
+		t.Fatalf("got %+v, %v", r, err)
+	}
+	if len(queries) != 2 {
+		t.Errorf("expected 2 requests got %d", len(queries))
+	}
+
+	queries = queries[:0]
+	f.Versions = false
+	if _, err = f.Fetch(context.Background(), Query{Artists: []string{"Queen"}, Title: "Unknown"}); KindOf(err) != NotFound {
+		t.Errorf("expected NotFound got %v", err)
+	}
+	if len(queries) != 2 {
+		t.Errorf("expected 2 requests got %d", len(queries))
+	}
+
+	queries = queries[:0]
+	r, err = f.Fetch(context.Background(), Query{Artists: []string{"Borislav Slavov"}, Title: "I Want to Live"})
+	if err != nil || r.Synced == nil || len(queries) != 1 {
+		t.Errorf("expected the synced exact record with one request got %+v, %v after %d", r, err, len(queries))
+	}
+}
+
+func TestLRCLibBrokenResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html>maintenance</html>`))
+	}))
+	defer server.Close()
+	f := LRCLibFetcher{BaseURL: server.URL}
+	if _, err := f.Fetch(context.Background(), Query{Artists: []string{"Queen"}, Title: "T"}); KindOf(err) != ProviderDown {
+		t.Errorf("expected ProviderDown got %v", err)
+	}
+}
@@ -2,7 +2,11 @@
 // for handling lyrics fething.
 package lyrics
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
+)
 
 // Fetcher should provide lyrics
 // given the songs artist and it's title.
@@ -19,9 +23,83 @@ var ErrLyricsNotFound = errors.New("lyrics for the requested song couldn't be fo
 // needed for the Lyricer app to print to the
 // console.
 type SongInfo struct {
-	Author string
-	Title  string
-	Lyrics string
+	// TrackID is the spotify id of the song,
+	// empty if unknown.
+	TrackID string
+	Author  string
+	Title   string
+	// Album and Duration help to tell the right
+	// search result apart, empty if unknown.
+	Album    string
+	Duration time.Duration
+	// Artists are all of the artists of the song,
+	// Author is the main one. ISRC is the songs
+	// recording code. Both are optional.
+	Artists []string
+	ISRC    string
+	Lyrics  string
+	// Synced are the time-synced lyrics,
+	// nil if they weren't fetched.
+	Synced *Lyrics
+	// Provider is the name of the provider
+	// which found the lyrics, if the Fetcher
+	// reports it like the Chain does.
+	Provider string
+	// URL, Copyright and Language describe the
+	// fetched lyrics if the provider reports them.
+	URL       string
+	Copyright string
+	Language  string
+	// ExpectedLanguage is the language the lyrics
+	// are expected in, empty if unknown.
+	ExpectedLanguage string
+	// Instrumental is set if the song
+	// turned out to have no lyrics.
+	Instrumental bool
+	// Confidence is how sure the provider is the
+	// lyrics are of the song, zero if unknown.
+	Confidence float64
+	// Versions are all of the versions of the lyrics
+	// fetched by FetchContext, the set one at the Version
+	// index. Empty if only one version was found.
+	Versions []*Result
+	Version  int
+}
+
+// Query returns the Query describing the song.
+func (s *SongInfo) Query() Query {
+	artists := s.Artists
+	if len(artists) == 0 && s.Author != "" {
+		artists = []string{s.Author}
+	}
+	return Query{
+		Artists:   artists,
+		Title:     s.Title,
+		Album:     s.Album,
+		Duration:  s.Duration,
+		ISRC:      s.ISRC,
+		SpotifyID: s.TrackID,
+		Language:  s.ExpectedLanguage,
+	}
+}
+
+// sourceFetcher is a Fetcher reporting
+// the provider which found the lyrics.
+type sourceFetcher interface {
+	FetchLyricsFrom(author, title string) (string, string, error)
+}
+
+// syncedSourceFetcher is a SyncedFetcher reporting
+// the provider which found the lyrics.
+type syncedSourceFetcher interface {
+	FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error)
+}
+
+// trackFetcher is a Fetcher which can make
+// use of the spotify track id, like the Cache.
+type trackFetcher interface {
+	FetchTrackLyrics(id, author, title string) (string, string, error)
+	FetchTrackSyncedLyrics(id, author, title string) (*Lyrics, string, error)
 }
 
 // FetchLyrics sets the SongInfos Lyrics field
@@ -40,6 +118,95 @@ type SongInfo struct {
 //  log.Printf("My songs lyrics are: %s", s.Lyrics)
 func (s *SongInfo) FetchLyrics(f Fetcher) error {
 	var err error
+	if tf, ok := f.(trackFetcher); ok && s.TrackID != "" {
+		s.Lyrics, s.Provider, err = tf.FetchTrackLyrics(s.TrackID, s.Author, s.Title)
+		return err
+	}
+	if sf, ok := f.(sourceFetcher); ok {
+		s.Lyrics, s.Provider, err = sf.FetchLyricsFrom(s.Author, s.Title)
+		return err
+	}
 	s.Lyrics, err = f.FetchLyrics(s.Author, s.Title)
 	return err
 }
+
+// FetchSyncedLyrics sets the SongInfos Synced field
+// to the return value of the SyncedFetcher FetchSyncedLyrics
+// call and the Lyrics field to its plain text.
+func (s *SongInfo) FetchSyncedLyrics(f SyncedFetcher) error {
+	var synced *Lyrics
+	var provider string
+	var err error
+	if tf, ok := f.(trackFetcher); ok && s.TrackID != "" {
+		synced, provider, err = tf.FetchTrackSyncedLyrics(s.TrackID, s.Author, s.Title)
+	} else if sf, ok := f.(syncedSourceFetcher); ok {
+		synced, provider, err = sf.FetchSyncedLyricsFrom(s.Author, s.Title)
+	} else {
+		synced, err = f.FetchSyncedLyrics(s.Author, s.Title)
+	}
+	if err != nil {
+		return err
+	}
+	s.Provider = provider
+	s.Synced = synced
+	s.Lyrics = synced.Text()
+	return nil
+}
+
+// Fetch fetches the synced lyrics if the Fetcher
+// is a SyncedFetcher as well, falling back to the
+// plain lyrics if they couldn't be fetched.
+func (s *SongInfo) Fetch(f Fetcher) error {
+	s.Synced = nil
+	if sf, ok := f.(SyncedFetcher); ok {
+		if err := s.FetchSyncedLyrics(sf); err == nil && s.Synced.Synced() {
+			return nil
+		}
+		s.Synced = nil
+	}
+	return s.FetchLyrics(f)
+}
+
+// FetchContext sets the SongInfos lyrics fields from the
+// Result of the QueryFetcher for the songs Query.
+// Use Adapt to fetch with the Fetcher. Language of the
+// lyrics is detected if the Result doesn't have it.
+//
+// If the song is instrumental the Instrumental field
+// is set and the Instrumental *Error is returned.
+func (s *SongInfo) FetchContext(ctx context.Context, f QueryFetcher) error {
+	r, err := f.Fetch(ctx, s.Query())
+	s.Instrumental = KindOf(err) == Instrumental
+	if err != nil {
+		return err
+	}
+	r.detectLanguage()
+	s.Versions = nil
+	if len(r.Versions) > 0 {
+		s.Versions = r.AllVersions()
+	}
+	s.setResult(r)
+	s.Version = 0
+	return nil
+}
+
+// SetVersion sets the lyrics fields to the version
+// of the lyrics with the given index in Versions.
+func (s *SongInfo) SetVersion(i int) {
+	if i < 0 || i >= len(s.Versions) {
+		return
+	}
+	s.Versions[i].detectLanguage()
+	s.setResult(s.Versions[i])
+	s.Version = i
+}
+
+func (s *SongInfo) setResult(r *Result) {
+	s.Lyrics = r.Lyrics
+	s.Synced = r.Synced
+	s.Provider = r.Provider
+	s.URL = r.URL
+	s.Copyright = r.Copyright
+	s.Language = r.Language
+	s.Confidence = r.Confidence
+}
@@ -0,0 +1,223 @@
+package lyrics
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrLowConfidence is returned by the Matcher if none
+// of the hits matched the song well enough.
+// Providers should treat it as ErrLyricsNotFound.
+var ErrLowConfidence = errors.New("no search result matches the song well enough")
+
+// DefaultMatchThreshold is the confidence the
+// best hit needs to be accepted by default.
+const DefaultMatchThreshold = 0.65
+
+// Hit is a single search result returned by
+// the lyrics provider.
+type Hit struct {
+	Artist   string
+	Title    string
+	Album    string
+	Duration time.Duration
+	// Ref is what the provider needs to fetch
+	// the lyrics of the hit, like its url or id.
+	Ref string
+}
+
+// Weights of the fields in the Score.
+// Fields missing in either the song or the hit
+// are skipped and the rest is scaled up.
+const (
+	titleWeight    = 0.5
+	artistWeight   = 0.3
+	albumWeight    = 0.1
+	durationWeight = 0.1
+)
+
+// Score returns how well the hit matches the song,
+// from 0 for no match to 1 for the exact match.
+// Titles are compared without the version and featuring
+// clauses, artists without the collaborators and
+// everything is compared folded.
+func Score(song SongInfo, h Hit) float64 {
+	score := titleWeight * similarity(matchTitle(song.Title), matchTitle(h.Title))
+	total := titleWeight
+	if song.Author != "" && h.Artist != "" {
+		artist := similarity(Fold(song.Author), Fold(h.Artist))
+		// Hit can list only one of the collaborators.
+		if main := similarity(Fold(MainArtist(song.Author)), Fold(MainArtist(h.Artist))); main > artist {
+			artist = main
+		}
+		score += artistWeight * artist
+		total += artistWeight
+	}
+	if song.Album != "" && h.Album != "" {
+		score += albumWeight * similarity(matchTitle(song.Album), matchTitle(h.Album))
+		total += albumWeight
+	}
+	if song.Duration > 0 && h.Duration > 0 {
+		score += durationWeight * durationSimilarity(song.Duration, h.Duration)
+		total += durationWeight
+	}
+	return score / total
+}
+
+func matchTitle(title string) string {
+	return Fold(StripVersion(StripFeaturing(title)))
+}
+
+// durationSimilarity is 1 for durations differing by up
+// to 2 seconds falling linearly to 0 at 30 seconds.
+func durationSimilarity(a, b time.Duration) float64 {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	const exact, none = 2 * time.Second, 30 * time.Second
+	switch {
+	case diff <= exact:
+		return 1
+	case diff >= none:
+		return 0
+	}
+	return 1 - float64(diff-exact)/float64(none-exact)
+}
+
+// similarity returns the better of the edit distance
+// ratio and the token set ratio, so both typos and
+// reordered or extra words are tolerated.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	if a == "" || b == "" {
+		return 0
+	}
+	edit := levenshteinRatio(a, b)
+	tokens := tokenSetRatio(a, b)
+	if tokens > edit {
+		return tokens
+	}
+	return edit
+}
+
+func levenshteinRatio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// tokenSetRatio compares the common words of both
+// strings with each of them, so "the beatles" matches
+// "beatles the" and "hey jude" matches "hey jude remix"
+// better than a plain edit distance would.
+func tokenSetRatio(a, b string) float64 {
+	wa, wb := wordSet(a), wordSet(b)
+	common, onlyA, onlyB := []string{}, []string{}, []string{}
+	for w := range wa {
+		if wb[w] {
+			common = append(common, w)
+		} else {
+			onlyA = append(onlyA, w)
+		}
+	}
+	for w := range wb {
+		if !wa[w] {
+			onlyB = append(onlyB, w)
+		}
+	}
+	if len(common) == 0 {
+		return 0
+	}
+	sort.Strings(common)
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+	base := strings.Join(common, " ")
+	withA := strings.TrimSpace(base + " " + strings.Join(onlyA, " "))
+	withB := strings.TrimSpace(base + " " + strings.Join(onlyB, " "))
+	best := levenshteinRatio(withA, withB)
+	// Penalize the subset matches slightly so the exact
+	// word sets always win over the partial ones.
+	if r := 0.95 * levenshteinRatio(base, withA); r > best && len(onlyA) == 0 {
+		best = r
+	}
+	if r := 0.95 * levenshteinRatio(base, withB); r > best && len(onlyB) == 0 {
+		best = r
+	}
+	return best
+}
+
+func wordSet(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		set[strings.Trim(w, ".,!?'\"()[]")] = true
+	}
+	delete(set, "")
+	return set
+}
+
+// Matcher picks the search hit best
+// matching the song.
+type Matcher struct {
+	// Threshold is the minimal confidence
+	// of the accepted hit.
+	Threshold float64
+}
+
+// DefaultMatcher is the Matcher with
+// the DefaultMatchThreshold.
+var DefaultMatcher = Matcher{Threshold: DefaultMatchThreshold}
+
+// Best returns the hit best matching the song and its confidence.
+// Returns ErrLyricsNotFound if there are no hits and
+// ErrLowConfidence if the best one is below the threshold.
+func (m Matcher) Best(song SongInfo, hits []Hit) (Hit, float64, error) {
+	if len(hits) == 0 {
+		return Hit{}, 0, ErrLyricsNotFound
+	}
+	best, bestScore := 0, -1.0
+	for i, h := range hits {
+		// Ties are won by the earlier hit
+		// as providers order them by relevance.
+		if s := Score(song, h); s > bestScore {
+			best, bestScore = i, s
+		}
+	}
+	if bestScore < m.Threshold {
+		return hits[best], bestScore, ErrLowConfidence
+	}
+	return hits[best], bestScore, nil
+}
@@ -0,0 +1,70 @@
+package lyrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScore(t *testing.T) {
+	song := SongInfo{
+		Author:   "Queen",
+		Title:    "Bohemian Rhapsody - Remastered 2011",
+		Album:    "A Night At The Opera",
+		Duration: 354 * time.Second,
+	}
+	cases := []struct {
+		name string
+		hit  Hit
+		min  float64
+		max  float64
+	}{
+		{"exact", Hit{Artist: "Queen", Title: "Bohemian Rhapsody", Album: "A Night at the Opera", Duration: 355 * time.Second}, 0.99, 1},
+		{"typo", Hit{Artist: "Qeen", Title: "Bohemian Rapsody"}, 0.8, 0.99},
+		{"other version", Hit{Artist: "Queen", Title: "Bohemian Rhapsody (Live Aid)", Duration: 250 * time.Second}, 0.8, 0.95},
+		{"cover", Hit{Artist: "Panic! At The Disco", Title: "Bohemian Rhapsody"}, 0.5, DefaultMatchThreshold},
+		{"other song", Hit{Artist: "Queen", Title: "Another One Bites The Dust"}, 0, DefaultMatchThreshold},
+	}
+	for _, c := range cases {
+		if got := Score(song, c.hit); got < c.min || got > c.max {
+			t.Errorf("%s: Score = %.2f expected between %.2f and %.2f", c.name, got, c.min, c.max)
+		}
+	}
+}
+
+func TestScoreIgnoresMissingFields(t *testing.T) {
+	song := SongInfo{Author: "Queen", Title: "Bohemian Rhapsody"}
+	hit := Hit{Artist: "Queen", Title: "Bohemian Rhapsody", Album: "Greatest Hits", Duration: time.Minute}
+	if got := Score(song, hit); got != 1 {
+		t.Errorf("Score = %.2f expected 1", got)
+	}
+}
+
+func TestMatcherBest(t *testing.T) {
+	song := SongInfo{Author: "Sigur Rós", Title: "Hoppípolla"}
+	hits := []Hit{
+		{Artist: "Sigur Ros Tribute Band", Title: "Hoppipolla (Cover)", Ref: "cover"},
+		{Artist: "Sigur Ros", Title: "Hoppipolla", Ref: "original"},
+		{Artist: "Sigur Rós", Title: "Glósóli", Ref: "other"},
+	}
+	hit, confidence, err := DefaultMatcher.Best(song, hits)
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if hit.Ref != "original" || confidence != 1 {
+		t.Errorf("got %s with %.2f expected original with 1", hit.Ref, confidence)
+	}
+}
+
+func TestMatcherBestRejectsLowConfidence(t *testing.T) {
+	song := SongInfo{Author: "Queen", Title: "Bohemian Rhapsody"}
+	hits := []Hit{{Artist: "Metallica", Title: "One"}}
+	if _, _, err := DefaultMatcher.Best(song, hits); err != ErrLowConfidence {
+		t.Errorf("expected ErrLowConfidence got %v", err)
+	}
+	if _, _, err := (Matcher{Threshold: 0}).Best(song, hits); err != nil {
+		t.Errorf("zero threshold should accept any hit, got %v", err)
+	}
+	if _, _, err := DefaultMatcher.Best(song, nil); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound for no hits got %v", err)
+	}
+}
@@ -0,0 +1,298 @@
+package lyrics
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldReplacer maps letters which don't decompose
+// into a base letter and a diacritic, and typographic
+// punctuation, to their ASCII counterparts.
+var foldReplacer = strings.NewReplacer(
+	"ł", "l", "Ł", "l", "ø", "o", "Ø", "o", "đ", "d", "Đ", "d",
+	"ß", "ss", "æ", "ae", "Æ", "ae", "œ", "oe", "Œ", "oe", "ı", "i",
+	"’", "'", "‘", "'", "`", "'", "´", "'", "“", "\"", "”", "\"",
+	"–", "-", "—", "-", "‐", "-", "…", "...", "&", "and",
+)
+
+// Fold returns s lower cased, without diacritics and with
+// the whitespace collapsed, for the comparison of
+// artists and titles.
+//
+// Example:
+//
+//	lyrics.Fold("  Sigur Rós – Hoppípolla ") // "sigur ros - hoppipolla"
+func Fold(s string) string {
+	s = strings.ToLower(foldReplacer.Replace(stripMarks(s)))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// stripMarks removes the diacritical marks
+// decomposing the letters first.
+func stripMarks(s string) string {
+	sb := strings.Builder{}
+	for _, r := range norm.NFD.String(s) {
+		if !unicode.Is(unicode.Mn, r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+var (
+	// versionWords mark the title suffixes describing
+	// the release version rather than the song.
+	versionWords = `remaster(?:ed)?|live|radio edit|edit|single version|album version|` +
+		`version|mono|stereo|mix|remix|acoustic|demo|bonus track|deluxe|explicit|` +
+		`clean|instrumental|re-?recorded|anniversary|extended|original|unplugged|session`
+	// versionSuffixRe matches " - Remastered 2011" like suffixes.
+	versionSuffixRe = regexp.MustCompile(`(?i)\s+-\s+[^-]*\b(?:` + versionWords + `)\b.*$`)
+	// versionGroupRe matches "(Live)" or "[2011 Remaster]" like groups.
+	versionGroupRe = regexp.MustCompile(`(?i)\s*[(\[][^)\]]*\b(?:` + versionWords + `)\b[^)\]]*[)\]]`)
+	// featGroupRe matches "(feat. X)" or "[with X]" like groups.
+	featGroupRe = regexp.MustCompile(`(?i)\s*[(\[]\s*(?:feat\.?|ft\.?|featuring|with)\s[^)\]]*[)\]]`)
+	// featClauseRe matches unbracketed "feat. X" up to the end or a dash.
+	featClauseRe = regexp.MustCompile(`(?i)\s+(?:feat\.?|ft\.?|featuring)\s.*?(\s+-\s+|$)`)
+	// anyGroupRe matches any bracketed group.
+	anyGroupRe = regexp.MustCompile(`\s*[(\[][^)\]]*[)\]]`)
+	// artistSeparatorRe splits the artist into the main one
+	// and the rest of the collaborators.
+	artistSeparatorRe = regexp.MustCompile(`(?i)\s*(?:,|&|\band\b|\bx\b|\bvs\.?|\bfeat\.?|\bft\.?|\bfeaturing\b|\bwith\b)\s*`)
+)
+
+// StripVersion removes the release version descriptions
+// like " - Remastered 2011", " - Live at Wembley",
+// " - Radio Edit" or "(Acoustic)" from the title.
+func StripVersion(title string) string {
+	stripped := versionGroupRe.ReplaceAllString(title, "")
+	stripped = versionSuffixRe.ReplaceAllString(stripped, "")
+	return cleanSpaces(stripped, title)
+}
+
+// StripFeaturing removes the featured artists clauses
+// like "(feat. X)", "[with X]" or "ft. X" from the title.
+func StripFeaturing(title string) string {
+	stripped := featGroupRe.ReplaceAllString(title, "")
+	stripped = featClauseRe.ReplaceAllString(stripped, "$1")
+	return cleanSpaces(stripped, title)
+}
+
+// MainArtist returns the first artist of the
+// collaboration like "A feat. B" or "A & B".
+func MainArtist(artist string) string {
+	parts := artistSeparatorRe.Split(artist, 2)
+	return cleanSpaces(parts[0], artist)
+}
+
+// cleanSpaces trims s falling back to the
+// original if nothing was left of it.
+func cleanSpaces(s, original string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	s = strings.TrimRight(s, " -")
+	if s == "" {
+		return strings.TrimSpace(original)
+	}
+	return s
+}
+
+// CandidateQuery is an artist and title
+// pair to look the lyrics up with.
+type CandidateQuery struct {
+	Author string
+	Title  string
+}
+
+// CandidateQueries returns queries to look the song up with,
+// ordered from the exact one to the loosest. Duplicates
+// are removed so the first query is always the original.
+//
+// Example:
+//
+//	lyrics.CandidateQueries("Queen", "Bohemian Rhapsody - Remastered 2011")
+//	// {Queen, Bohemian Rhapsody - Remastered 2011}
+//	// {Queen, Bohemian Rhapsody}
+func CandidateQueries(author, title string) []CandidateQuery {
+	noFeat := StripFeaturing(title)
+	noVersion := StripVersion(noFeat)
+	bare := cleanSpaces(anyGroupRe.ReplaceAllString(noVersion, ""), noVersion)
+	if i := strings.Index(bare, " - "); i > 0 {
+		bare = bare[:i]
+	}
+	mainArtist := MainArtist(author)
+
+	candidates := []CandidateQuery{
+		{author, title},
+		{author, noFeat},
+		{author, noVersion},
+		{mainArtist, noVersion},
+		{mainArtist, bare},
+		{removeDiacritics(mainArtist), removeDiacritics(bare)},
+	}
+	seen := map[CandidateQuery]bool{}
+	unique := []CandidateQuery{}
+	for _, c := range candidates {
+		c.Author = strings.TrimSpace(c.Author)
+		c.Title = strings.TrimSpace(c.Title)
+		if seen[c] || c.Title == "" {
+			continue
+		}
+		seen[c] = true
+		unique = append(unique, c)
+	}
+	return unique
+}
+
+// letterReplacer maps the letters which
+// don't decompose, keeping their case.
+var letterReplacer = strings.NewReplacer(
+	"ł", "l", "Ł", "L", "ø", "o", "Ø", "O", "đ", "d", "Đ", "D",
+)
+
+// removeDiacritics strips the diacritics keeping the case.
+func removeDiacritics(s string) string {
+	return letterReplacer.Replace(stripMarks(s))
+}
+
+// Normalizer is a Fetcher and QueryFetcher decorator
+// retrying the lookup with progressively looser
+// CandidateQueries until the lyrics are found.
+type Normalizer struct {
+	Fetcher Fetcher
+}
+
+// NewNormalizer returns Normalizer around the Fetcher.
+func NewNormalizer(f Fetcher) *Normalizer {
+	return &Normalizer{Fetcher: f}
+}
+
+// FetchLyrics implements Fetcher.
+func (n *Normalizer) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := n.FetchLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns
+// name of the provider which found them, if the
+// wrapped Fetcher reports it.
+func (n *Normalizer) FetchLyricsFrom(author, title string) (string, string, error) {
+	var errs candidateErrors
+	for _, q := range CandidateQueries(author, title) {
+		var lyrics, provider string
+		var err error
+		if sf, ok := n.Fetcher.(sourceFetcher); ok {
+			lyrics, provider, err = sf.FetchLyricsFrom(q.Author, q.Title)
+		} else {
+			lyrics, err = n.Fetcher.FetchLyrics(q.Author, q.Title)
+		}
+		if !errs.retry(err) {
+			return lyrics, provider, err
+		}
+	}
+	return "", "", errs.err(ErrLyricsNotFound)
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+// If the wrapped Fetcher is not a SyncedFetcher
+// ErrLyricsNotFound is returned.
+func (n *Normalizer) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := n.FetchSyncedLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom is FetchLyricsFrom for
+// the synced lyrics.
+func (n *Normalizer) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	sf, ok := n.Fetcher.(SyncedFetcher)
+	if !ok {
+		return nil, "", ErrLyricsNotFound
+	}
+	var errs candidateErrors
+	for _, q := range CandidateQueries(author, title) {
+		var lyrics *Lyrics
+		var provider string
+		var err error
+		if ssf, ok := n.Fetcher.(syncedSourceFetcher); ok {
+			lyrics, provider, err = ssf.FetchSyncedLyricsFrom(q.Author, q.Title)
+		} else {
+			lyrics, err = sf.FetchSyncedLyrics(q.Author, q.Title)
+		}
+		if !errs.retry(err) {
+			return lyrics, provider, err
+		}
+	}
+	return nil, "", errs.err(ErrLyricsNotFound)
+}
+
+// Fetch implements QueryFetcher. The wrapped Fetcher
+// is adapted with Adapt if it's not a QueryFetcher.
+func (n *Normalizer) Fetch(ctx context.Context, q Query) (*Result, error) {
+	f := Adapt(n.Fetcher)
+	var errs candidateErrors
+	for _, c := range CandidateQueries(q.Artist(), q.Title) {
+		r, err := f.Fetch(ctx, q.with(c))
+		if !errs.retry(err) {
+			return r, err
+		}
+	}
+	return nil, errs.err(&Error{Kind: NotFound})
+}
+
+// candidateErrors collects the errors of
+// the candidate queries of the Normalizer.
+type candidateErrors struct {
+	// failed is the first error other than NotFound.
+	failed   error
+	notFound error
+}
+
+// retry reports whether the next candidate should
+// be tried after the error. Errors other than NotFound,
+// like the provider being down, are tried past too, as
+// the other providers could still find the looser query.
+// Context errors and Instrumental end the lookup.
+func (e *candidateErrors) retry(err error) bool {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded || KindOf(err) == Instrumental {
+		return false
+	}
+	if KindOf(err) == NotFound {
+		e.notFound = err
+	} else if e.failed == nil {
+		e.failed = err
+	}
+	return true
+}
+
+// err returns the error of the lookup, the first error
+// other than NotFound if there was one, as it tells why
+// the lyrics might not have been found.
+func (e *candidateErrors) err(notFound error) error {
+	if e.failed != nil {
+		return e.failed
+	}
+	if e.notFound != nil {
+		return e.notFound
+	}
+	return notFound
+}
+
+// with returns the query for the candidate,
+// the main artist replaced by the candidates one.
+func (q Query) with(c CandidateQuery) Query {
+	q.Title = c.Title
+	if c.Author == q.Artist() {
+		return q
+	}
+	artists := []string{}
+	if c.Author != "" {
+		artists = append(artists, c.Author)
+	}
+	if len(q.Artists) > 1 {
+		artists = append(artists, q.Artists[1:]...)
+	}
+	q.Artists = artists
+	return q
+}
@@ -0,0 +1,134 @@
+package lyrics
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	cases := map[string]string{
+		"  Sigur Rós – Hoppípolla ": "sigur ros - hoppipolla",
+		"Łąki Łan":                  "laki lan",
+		"Don’t Stop Me Now":         "don't stop me now",
+		"Simon & Garfunkel":         "simon and garfunkel",
+		"Motörhead":                 "motorhead",
+	}
+	for in, out := range cases {
+		if got := Fold(in); got != out {
+			t.Errorf("Fold(%q) = %q expected %q", in, got, out)
+		}
+	}
+}
+
+func TestStripVersion(t *testing.T) {
+	cases := map[string]string{
+		"Song - Remastered 2011":          "Song",
+		"Song - 2011 Remaster":            "Song",
+		"Song - Live at Wembley":          "Song",
+		"Song - Radio Edit":               "Song",
+		"Song (Acoustic Version)":         "Song",
+		"Song [Live] - Remastered":        "Song",
+		"Song - Part 2":                   "Song - Part 2",
+		"Live and Let Die":                "Live and Let Die",
+		"Love Me Do - Mono / Remastered":  "Love Me Do",
+		"Remastered":                      "Remastered",
+		"Song (feat. X) - Single Version": "Song (feat. X)",
+	}
+	for in, out := range cases {
+		if got := StripVersion(in); got != out {
+			t.Errorf("StripVersion(%q) = %q expected %q", in, got, out)
+		}
+	}
+}
+
+func TestStripFeaturing(t *testing.T) {
+	cases := map[string]string{
+		"Song (feat. X)":             "Song",
+		"Song [with X & Y]":          "Song",
+		"Song ft. X":                 "Song",
+		"Song feat. X - Radio Edit":  "Song - Radio Edit",
+		"Song Without Features":      "Song Without Features",
+		"Stand by Me (with Strings)": "Stand by Me",
+	}
+	for in, out := range cases {
+		if got := StripFeaturing(in); got != out {
+			t.Errorf("StripFeaturing(%q) = %q expected %q", in, got, out)
+		}
+	}
+}
+
+func TestMainArtist(t *testing.T) {
+	cases := map[string]string{
+		"A feat. B": "A",
+		"A & B":     "A",
+		"A, B, C":   "A",
+		"Andromeda": "Andromeda",
+		"Xzibit":    "Xzibit",
+	}
+	for in, out := range cases {
+		if got := MainArtist(in); got != out {
+			t.Errorf("MainArtist(%q) = %q expected %q", in, got, out)
+		}
+	}
+}
+
+func TestCandidateQueries(t *testing.T) {
+	got := CandidateQueries("Björk & Friends", "Jóga (feat. X) - Live")
+	expected := []CandidateQuery{
+		{"Björk & Friends", "Jóga (feat. X) - Live"},
+		{"Björk & Friends", "Jóga - Live"},
+		{"Björk & Friends", "Jóga"},
+		{"Björk", "Jóga"},
+		{"Bjork", "Joga"},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Got %v expected %v", got, expected)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("Candidate %d is %v expected %v", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestNormalizerRetriesLooserQueries(t *testing.T) {
+	f := mapFetcher{"Queen|Bohemian Rhapsody": "Is this the real life?"}
+	n := NewNormalizer(f)
+	lyrics, err := n.FetchLyrics("Queen", "Bohemian Rhapsody - Remastered 2011")
+	if err != nil || lyrics != "Is this the real life?" {
+		t.Errorf("Got %q, %v", lyrics, err)
+	}
+	if _, err = n.FetchLyrics("Queen", "Unknown"); err != ErrLyricsNotFound {
+		t.Errorf("Expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestNormalizerRetriesPastProviderDown(t *testing.T) {
+	down := &Error{Kind: ProviderDown, Provider: "genius", Err: ErrNoToken}
+	c := NewChain(
+		Provider{"genius", &stubFetcher{err: down}},
+		Provider{"lrclib", mapFetcher{"Queen|Bohemian Rhapsody": "Is this the real life?"}},
+	)
+	n := NewNormalizer(c)
+	lyrics, provider, err := n.FetchLyricsFrom("Queen", "Bohemian Rhapsody - Remastered 2011")
+	if err != nil || lyrics != "Is this the real life?" || provider != "lrclib" {
+		t.Errorf("Got %q from %q, %v", lyrics, provider, err)
+	}
+	r, err := n.Fetch(context.Background(), Query{Artists: []string{"Queen"}, Title: "Bohemian Rhapsody - Remastered 2011"})
+	if err != nil || r.Lyrics != "Is this the real life?" {
+		t.Errorf("Got %+v, %v", r, err)
+	}
+	if _, err = n.FetchLyrics("Queen", "Unknown"); KindOf(err) != ProviderDown {
+		t.Errorf("Expected the provider error got %v", err)
+	}
+}
+
+type mapFetcher map[string]string
+
+func (f mapFetcher) FetchLyrics(author, title string) (string, error) {
+	l, ok := f[author+"|"+title]
+	if !ok {
+		return "", ErrLyricsNotFound
+	}
+	return l, nil
+}
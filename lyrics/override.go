@@ -0,0 +1,294 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OverrideProvider is the provider name
+// reported for the overridden lyrics.
+const OverrideProvider = "override"
+
+// Files in the Overrides Dir the sync offsets and
+// the keys of the chosen lyrics versions are stored in.
+const (
+	offsetsFile  = "offsets.json"
+	versionsFile = "versions.json"
+)
+
+// Overrides is a Fetcher and QueryFetcher decorator
+// returning the lyrics saved by the user for the spotify
+// track instead of the ones the wrapped Fetcher finds.
+//
+// Lyrics are stored in Dir, one file per track named
+// after its id, "<id>.lrc" for the synced lyrics and
+// "<id>.txt" for the plain ones, so they can be
+// edited by hand too. Sync offsets saved for the
+// tracks are kept in the "offsets.json" file there and
+// the lyrics versions chosen in the "versions.json" one.
+// The chosen version is put first when it's fetched.
+//
+// Overrides need the track id, lyrics fetched
+// without it are always fetched by the Fetcher.
+type Overrides struct {
+	Fetcher Fetcher
+	Dir     string
+
+	mu sync.Mutex
+}
+
+// NewOverrides returns Overrides around the Fetcher
+// with the lyrics stored in the dir.
+func NewOverrides(f Fetcher, dir string) *Overrides {
+	return &Overrides{Fetcher: f, Dir: dir}
+}
+
+func (o *Overrides) path(id, ext string) string {
+	return filepath.Join(o.Dir, filepath.Base(id)+ext)
+}
+
+// Lyrics returns the lyrics saved for the track.
+// ErrLyricsNotFound is returned if there are none.
+func (o *Overrides) Lyrics(id string) (*Lyrics, error) {
+	if o.Dir == "" || id == "" {
+		return nil, ErrLyricsNotFound
+	}
+	l, err := readLRC(o.path(id, ".lrc"))
+	if os.IsNotExist(err) {
+		var data []byte
+		data, err = ioutil.ReadFile(o.path(id, ".txt"))
+		if err == nil {
+			l = PlainLyrics(string(data))
+		}
+	}
+	if os.IsNotExist(err) {
+		return nil, ErrLyricsNotFound
+	}
+	return l, err
+}
+
+// Save stores the lyrics of the track replacing the
+// ones saved before. Synced lyrics are stored as LRC.
+func (o *Overrides) Save(id string, l *Lyrics) error {
+	if err := os.MkdirAll(o.Dir, 0755); err != nil {
+		return err
+	}
+	path, other, data := o.path(id, ".txt"), o.path(id, ".lrc"), l.Text()+"\n"
+	if l.Synced() {
+		path, other, data = other, path, l.LRC()
+	}
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		return err
+	}
+	if err := os.Remove(other); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Remove deletes the lyrics saved for the track,
+// so they are fetched again.
+func (o *Overrides) Remove(id string) error {
+	for _, ext := range []string{".lrc", ".txt"} {
+		if err := os.Remove(o.path(id, ext)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Offset returns the sync offset saved for
+// the track, zero if there is none.
+func (o *Overrides) Offset(id string) time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	offsets := map[string]int64{}
+	o.read(offsetsFile, &offsets)
+	return time.Duration(offsets[id]) * time.Millisecond
+}
+
+// SaveOffset stores the sync offset of the track,
+// as the View offset. Zero offset is removed.
+func (o *Overrides) SaveOffset(id string, d time.Duration) error {
+	if o.Dir == "" || id == "" {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	offsets := map[string]int64{}
+	o.read(offsetsFile, &offsets)
+	if d == 0 {
+		delete(offsets, id)
+	} else {
+		offsets[id] = d.Milliseconds()
+	}
+	return o.write(offsetsFile, offsets)
+}
+
+// Version returns the Key of the lyrics version
+// chosen for the track, empty if there is none.
+func (o *Overrides) Version(id string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	versions := map[string]string{}
+	o.read(versionsFile, &versions)
+	return versions[id]
+}
+
+// SaveVersion remembers the Key of the lyrics
+// version chosen for the track.
+func (o *Overrides) SaveVersion(id, key string) error {
+	if o.Dir == "" || id == "" {
+		return nil
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	versions := map[string]string{}
+	o.read(versionsFile, &versions)
+	versions[id] = key
+	return o.write(versionsFile, versions)
+}
+
+// read decodes the json file of the Dir into v.
+// Missing or broken file is left as it is.
+func (o *Overrides) read(name string, v interface{}) {
+	if o.Dir == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(filepath.Join(o.Dir, name))
+	if err == nil {
+		json.Unmarshal(data, v)
+	}
+}
+
+// write stores v as the json file of the Dir.
+func (o *Overrides) write(name string, v interface{}) error {
+	if err := os.MkdirAll(o.Dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(o.Dir, name)
+	if err = ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// FetchLyrics implements Fetcher.
+func (o *Overrides) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := o.FetchTrackLyrics("", author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns
+// name of the provider which found them, if the
+// wrapped Fetcher reports it.
+func (o *Overrides) FetchLyricsFrom(author, title string) (string, string, error) {
+	return o.FetchTrackLyrics("", author, title)
+}
+
+// FetchTrackLyrics returns the lyrics saved for the
+// spotify track with the given id or fetches them.
+func (o *Overrides) FetchTrackLyrics(id, author, title string) (string, string, error) {
+	if l, err := o.Lyrics(id); err != ErrLyricsNotFound {
+		if err != nil {
+			return "", "", err
+		}
+		return l.Text(), OverrideProvider, nil
+	}
+	if tf, ok := o.Fetcher.(trackFetcher); ok {
+		return tf.FetchTrackLyrics(id, author, title)
+	}
+	if sf, ok := o.Fetcher.(sourceFetcher); ok {
+		return sf.FetchLyricsFrom(author, title)
+	}
+	lyrics, err := o.Fetcher.FetchLyrics(author, title)
+	return lyrics, "", err
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+func (o *Overrides) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := o.FetchTrackSyncedLyrics("", author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom is FetchLyricsFrom for
+// the synced lyrics.
+func (o *Overrides) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	return o.FetchTrackSyncedLyrics("", author, title)
+}
+
+// FetchTrackSyncedLyrics is FetchTrackLyrics for the
+// synced lyrics. If the plain lyrics are saved for the
+// track ErrLyricsNotFound is returned, so they are
+// used instead of the fetched synced ones.
+func (o *Overrides) FetchTrackSyncedLyrics(id, author, title string) (*Lyrics, string, error) {
+	if l, err := o.Lyrics(id); err != ErrLyricsNotFound {
+		if err != nil {
+			return nil, "", err
+		}
+		if !l.Synced() {
+			return nil, "", ErrLyricsNotFound
+		}
+		return l, OverrideProvider, nil
+	}
+	if tf, ok := o.Fetcher.(trackFetcher); ok {
+		return tf.FetchTrackSyncedLyrics(id, author, title)
+	}
+	sf, ok := o.Fetcher.(SyncedFetcher)
+	if !ok {
+		return nil, "", ErrLyricsNotFound
+	}
+	if ssf, ok := o.Fetcher.(syncedSourceFetcher); ok {
+		return ssf.FetchSyncedLyricsFrom(author, title)
+	}
+	lyrics, err := sf.FetchSyncedLyrics(author, title)
+	return lyrics, "", err
+}
+
+// Fetch implements QueryFetcher returning the lyrics
+// saved for the track with the SpotifyID of the query,
+// or the fetched ones with the version chosen for
+// the track first. The wrapped Fetcher is adapted
+// with Adapt if it's not a QueryFetcher.
+func (o *Overrides) Fetch(ctx context.Context, q Query) (*Result, error) {
+	l, err := o.Lyrics(q.SpotifyID)
+	if err == ErrLyricsNotFound {
+		r, err := Adapt(o.Fetcher).Fetch(ctx, q)
+		if err != nil || len(r.Versions) == 0 {
+			return r, err
+		}
+		return chooseVersion(r, o.Version(q.SpotifyID)), nil
+	}
+	if err != nil {
+		return nil, &Error{Kind: Unknown, Provider: OverrideProvider, Err: err}
+	}
+	r := &Result{Lyrics: l.Text(), Provider: OverrideProvider, Confidence: 1}
+	if l.Synced() {
+		r.Synced = l
+	}
+	r.detectLanguage()
+	return r, nil
+}
+
+// chooseVersion returns the Result with the version
+// of the given Key first, the Result itself if
+// there is no such version.
+func chooseVersion(r *Result, key string) *Result {
+	versions := r.AllVersions()
+	for i, v := range versions {
+		if i > 0 && v.Key() == key {
+			rest := append(append([]*Result{}, versions[:i]...), versions[i+1:]...)
+			return withVersions(append([]*Result{v}, rest...))
+		}
+	}
+	return r
+}
@@ -0,0 +1,120 @@
+package lyrics
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	fetched, _ := ParseLRC(strings.NewReader("[00:01.00]wrong\n"))
+	stub := &queryStub{result: &Result{Lyrics: "wrong", Synced: fetched, Provider: "lrclib"}}
+	o := NewOverrides(stub, dir)
+	q := Query{Artists: []string{"Queen"}, Title: "T", SpotifyID: "id"}
+
+	r, err := o.Fetch(context.Background(), q)
+	if err != nil || r.Provider != "lrclib" {
+		t.Fatalf("expected fetched lyrics got %+v, %v", r, err)
+	}
+
+	if err = o.Save("id", PlainLyrics("right\nlyrics")); err != nil {
+		t.Fatal(err)
+	}
+	r, err = o.Fetch(context.Background(), q)
+	if err != nil || r.Lyrics != "right\nlyrics" || r.Synced != nil || r.Provider != OverrideProvider {
+		t.Errorf("expected plain override got %+v, %v", r, err)
+	}
+	if _, _, err = o.FetchTrackSyncedLyrics("id", "Queen", "T"); err != ErrLyricsNotFound {
+		t.Errorf("plain override should hide the synced lyrics, got %v", err)
+	}
+	if lyrics, provider, _ := o.FetchTrackLyrics("id", "Queen", "T"); lyrics != "right\nlyrics" || provider != OverrideProvider {
+		t.Errorf("got %q from %q", lyrics, provider)
+	}
+	if lyrics, _ := o.FetchLyrics("Queen", "T"); lyrics != "wrong" {
+		t.Errorf("lyrics fetched without the id should not be overridden, got %q", lyrics)
+	}
+
+	synced, _ := ParseLRC(strings.NewReader("[00:02.00]right\n"))
+	if err = o.Save("id", synced); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "id.txt")); !os.IsNotExist(err) {
+		t.Errorf("plain override not replaced")
+	}
+	got, provider, err := o.FetchTrackSyncedLyrics("id", "Queen", "T")
+	if err != nil || provider != OverrideProvider || got.Lines[0].Time != 2*time.Second {
+		t.Errorf("expected synced override got %+v from %q, %v", got, provider, err)
+	}
+
+	if err = o.Remove("id"); err != nil {
+		t.Fatal(err)
+	}
+	if r, _ = o.Fetch(context.Background(), q); r.Provider != "lrclib" {
+		t.Errorf("removed override still used")
+	}
+}
+
+func TestOverridesOffset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	o := NewOverrides(nil, dir)
+	if d := o.Offset("id"); d != 0 {
+		t.Errorf("expected no offset got %s", d)
+	}
+	o.SaveOffset("id", 750*time.Millisecond)
+	o.SaveOffset("other", -time.Second)
+	restarted := NewOverrides(nil, dir)
+	if d := restarted.Offset("id"); d != 750*time.Millisecond {
+		t.Errorf("expected 750ms offset got %s", d)
+	}
+	restarted.SaveOffset("id", 0)
+	if d, other := o.Offset("id"), o.Offset("other"); d != 0 || other != -time.Second {
+		t.Errorf("got offsets %s and %s", d, other)
+	}
+}
+
+func TestOverridesVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	live := &Result{Lyrics: "live", Provider: "lrclib", URL: "http://lyrics/live"}
+	other := &Result{Lyrics: "other", Provider: "genius", URL: "http://other"}
+	stub := &queryStub{result: &Result{Lyrics: "album", Provider: "lrclib", URL: "http://lyrics/album", Versions: []*Result{live, other}}}
+	o := NewOverrides(stub, dir)
+	q := Query{Title: "T", SpotifyID: "id"}
+
+	if err = o.SaveVersion("id", live.Key()); err != nil {
+		t.Fatal(err)
+	}
+	r, err := o.Fetch(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, v := range r.AllVersions() {
+		got = append(got, v.Lyrics)
+	}
+	if strings.Join(got, " ") != "live album other" {
+		t.Errorf("chosen version not first, got %q", got)
+	}
+	if r, _ = o.Fetch(context.Background(), Query{Title: "T", SpotifyID: "other"}); r.Lyrics != "album" {
+		t.Errorf("version chosen for other track, got %q", r.Lyrics)
+	}
+}
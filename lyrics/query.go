@@ -0,0 +1,333 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryFetcher is the context aware Fetcher taking
+// everything known about the track. Fetching should
+// stop and return soon after the context is done.
+//
+// Errors should be of the *Error type, so the callers
+// can tell why the lyrics couldn't be fetched.
+type QueryFetcher interface {
+	Fetch(ctx context.Context, q Query) (*Result, error)
+}
+
+// Query describes the track the lyrics are fetched for.
+// Only the Title is required, providers use
+// whatever else they can.
+type Query struct {
+	// Artists of the track, main artist first.
+	Artists  []string
+	Title    string
+	Album    string
+	Duration time.Duration
+	// ISRC is the International Standard Recording Code.
+	ISRC string
+	// SpotifyID is the spotify id of the track.
+	SpotifyID string
+	// Language is the ISO 639-1 code of the language
+	// the lyrics are expected in, empty if unknown.
+	Language string
+}
+
+// Artist returns the main artist of the track.
+func (q Query) Artist() string {
+	if len(q.Artists) == 0 {
+		return ""
+	}
+	return q.Artists[0]
+}
+
+// song returns the SongInfo for matching the search
+// results against the query.
+func (q Query) song() SongInfo {
+	return SongInfo{
+		TrackID:  q.SpotifyID,
+		Author:   q.Artist(),
+		Title:    q.Title,
+		Album:    q.Album,
+		Duration: q.Duration,
+	}
+}
+
+// Result are the lyrics found by the provider
+// along with where they came from.
+type Result struct {
+	// Lyrics are the plain lyrics,
+	// text of the Synced ones if there are no others.
+	Lyrics string
+	// Synced are the time-synced lyrics,
+	// nil if the provider has none.
+	Synced *Lyrics
+	// Provider is the name of the provider which found them.
+	Provider string
+	// URL of the lyrics page, if there is one.
+	URL string
+	// Copyright notice the lyrics should be shown with.
+	Copyright string
+	// Language is the ISO 639-1 code of the lyrics,
+	// empty if it's unknown.
+	Language string
+	// Confidence is how sure the provider is these are
+	// the lyrics of the track, from 0 to 1, zero if unknown.
+	Confidence float64
+	// Versions are the other versions of the lyrics found,
+	// like of the live recording or another transcription,
+	// in the order of preference. Empty if there's one.
+	Versions []*Result
+}
+
+// AllVersions returns the versions of the lyrics,
+// the Result itself first, without their Versions.
+func (r *Result) AllVersions() []*Result {
+	first := *r
+	first.Versions = nil
+	return append([]*Result{&first}, r.Versions...)
+}
+
+// Key tells the version of the lyrics apart, by the
+// provider and the URL, to remember the chosen one.
+func (r *Result) Key() string {
+	return r.Provider + "|" + r.URL
+}
+
+// withVersions returns the first of the versions
+// with the other ones as its Versions, the versions
+// of the same lyrics skipped. Nil if there are none.
+func withVersions(versions []*Result) *Result {
+	if len(versions) == 0 {
+		return nil
+	}
+	first := *versions[0]
+	first.Versions = nil
+	seen := map[string]bool{first.Key(): true}
+	for _, v := range versions[1:] {
+		if !seen[v.Key()] {
+			seen[v.Key()] = true
+			first.Versions = append(first.Versions, v)
+		}
+	}
+	return &first
+}
+
+// detectLanguage sets the Language of the
+// lyrics if the provider didn't report it.
+func (r *Result) detectLanguage() {
+	if r.Language == "" {
+		r.Language, _ = DetectLanguage(r.Lyrics)
+	}
+}
+
+// matches reports whether the lyrics are in the language
+// expected by the query, or either is unknown.
+func (r *Result) matches(q Query) bool {
+	return q.Language == "" || r.Language == "" || r.Language == q.Language
+}
+
+// ErrorKind tells why the lyrics couldn't be fetched.
+type ErrorKind int
+
+// Kinds of the errors returned by the QueryFetchers.
+const (
+	// Unknown is the kind of the errors
+	// which are not of the *Error type.
+	Unknown ErrorKind = iota
+	// NotFound means the provider has no lyrics of the track.
+	NotFound
+	// RateLimited means the provider refused to answer
+	// because of too many requests.
+	RateLimited
+	// ProviderDown means the provider couldn't be reached
+	// or failed to answer.
+	ProviderDown
+	// Instrumental means the track has no lyrics to fetch.
+	Instrumental
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case NotFound:
+		return "not found"
+	case RateLimited:
+		return "rate limited"
+	case ProviderDown:
+		return "provider down"
+	case Instrumental:
+		return "instrumental"
+	}
+	return "unknown"
+}
+
+// Error is the error returned by the QueryFetchers.
+//
+// NotFound and Instrumental errors match ErrLyricsNotFound
+// with errors.Is, so they can be handled the old way.
+type Error struct {
+	Kind     ErrorKind
+	Provider string
+	// RetryAfter is how long the RateLimited
+	// provider asked to wait, 0 if it didn't.
+	RetryAfter time.Duration
+	// Err is the underlying error, if there is one.
+	Err error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.String()
+	if e.Provider != "" {
+		msg = e.Provider + ": " + msg
+	}
+	if e.Kind == RateLimited && e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %s", e.RetryAfter)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is ErrLyricsNotFound.
+func (e *Error) Is(target error) bool {
+	return target == ErrLyricsNotFound && (e.Kind == NotFound || e.Kind == Instrumental)
+}
+
+// KindOf returns the kind of the error.
+// ErrLyricsNotFound is of the NotFound kind.
+func KindOf(err error) ErrorKind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	if err == ErrLyricsNotFound {
+		return NotFound
+	}
+	return Unknown
+}
+
+// queryError turns the error into the *Error
+// of the provider. Errors of the *Error type
+// are only given the provider name if they miss it
+// and ErrLyricsNotFound becomes the NotFound error.
+// Context errors are returned as they are.
+func queryError(provider string, err error) error {
+	if err == nil || err == context.Canceled || err == context.DeadlineExceeded {
+		return err
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Provider == "" {
+			copied := *e
+			copied.Provider = provider
+			return &copied
+		}
+		return e
+	}
+	if err == ErrLyricsNotFound {
+		return &Error{Kind: NotFound, Provider: provider}
+	}
+	return &Error{Kind: ProviderDown, Provider: provider, Err: err}
+}
+
+// fetcherError turns the error back into the one
+// the Fetchers return, ErrLyricsNotFound if the
+// lyrics were not found.
+func fetcherError(err error) error {
+	if errors.Is(err, ErrLyricsNotFound) {
+		return ErrLyricsNotFound
+	}
+	return err
+}
+
+// responseError returns the error for the not OK
+// http response of the provider api.
+func responseError(provider string, resp *http.Response) error {
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &Error{Kind: NotFound, Provider: provider}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e := &Error{Kind: RateLimited, Provider: provider}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			e.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return e
+	}
+	return &Error{
+		Kind:     ProviderDown,
+		Provider: provider,
+		Err:      fmt.Errorf("responded with %s", resp.Status),
+	}
+}
+
+// Adapt returns the QueryFetcher fetching with the Fetcher.
+// Fetchers which implement QueryFetcher are returned as they are.
+//
+// The synced lyrics are fetched first if the Fetcher is
+// a SyncedFetcher, then the plain ones. Fetchers can't be
+// interrupted, so if the context is done the call finishes
+// in the background and its result is dropped.
+func Adapt(f Fetcher) QueryFetcher {
+	if qf, ok := f.(QueryFetcher); ok {
+		return qf
+	}
+	return adapter{f}
+}
+
+type adapter struct {
+	Fetcher Fetcher
+}
+
+func (a adapter) Fetch(ctx context.Context, q Query) (*Result, error) {
+	type fetched struct {
+		result *Result
+		err    error
+	}
+	done := make(chan fetched, 1)
+	go func() {
+		song := q.song()
+		err := song.Fetch(a.Fetcher)
+		done <- fetched{songResult(song), err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case f := <-done:
+		if f.err != nil {
+			return nil, queryError(f.result.Provider, f.err)
+		}
+		return f.result, nil
+	}
+}
+
+func songResult(s SongInfo) *Result {
+	return &Result{
+		Lyrics:   s.Lyrics,
+		Synced:   s.Synced,
+		Provider: s.Provider,
+	}
+}
+
+// newResult returns the Result of the synced lyrics
+// if they are synced or of the plain ones otherwise.
+func newResult(provider, plain string, synced *Lyrics) *Result {
+	r := &Result{Provider: provider, Lyrics: strings.TrimSpace(plain)}
+	if synced != nil && synced.Synced() {
+		r.Synced = synced
+	}
+	if r.Lyrics == "" && synced != nil {
+		r.Lyrics = synced.Text()
+	}
+	return r
+}
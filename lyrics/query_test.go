@@ -0,0 +1,245 @@
+package lyrics
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// queryStub is a QueryFetcher waiting for the delay
+// or the context and reporting if it was cancelled.
+type queryStub struct {
+	result    *Result
+	err       error
+	delay     time.Duration
+	cancelled chan bool
+	calls     int
+}
+
+func (f *queryStub) Fetch(ctx context.Context, q Query) (*Result, error) {
+	f.calls++
+	select {
+	case <-time.After(f.delay):
+		return f.result, f.err
+	case <-ctx.Done():
+		if f.cancelled != nil {
+			f.cancelled <- true
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// FetchLyrics implements Fetcher, so the stub can be a Provider.
+func (f *queryStub) FetchLyrics(author, title string) (string, error) {
+	r, err := f.Fetch(context.Background(), Query{Artists: []string{author}, Title: title})
+	if err != nil {
+		return "", fetcherError(err)
+	}
+	return r.Lyrics, nil
+}
+
+func TestAdaptErrors(t *testing.T) {
+	q := Query{Artists: []string{"A"}, Title: "T"}
+	_, err := Adapt(&stubFetcher{err: ErrLyricsNotFound}).Fetch(context.Background(), q)
+	if KindOf(err) != NotFound || !errors.Is(err, ErrLyricsNotFound) {
+		t.Errorf("expected NotFound error got %v", err)
+	}
+	down := errors.New("connection refused")
+	_, err = Adapt(&stubFetcher{err: down}).Fetch(context.Background(), q)
+	if KindOf(err) != ProviderDown || !errors.Is(err, down) {
+		t.Errorf("expected ProviderDown error got %v", err)
+	}
+	r, err := Adapt(&stubFetcher{lyrics: "la la"}).Fetch(context.Background(), q)
+	if err != nil || r.Lyrics != "la la" {
+		t.Errorf("got %+v, %v", r, err)
+	}
+}
+
+func TestAdaptContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := Adapt(&stubFetcher{lyrics: "slow", delay: time.Second}).Fetch(ctx, Query{Title: "T"})
+	if err != context.DeadlineExceeded || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("expected the deadline to stop the fetch, got %v after %s", err, time.Since(start))
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	cases := map[error]ErrorKind{
+		ErrLyricsNotFound:                      NotFound,
+		&Error{Kind: RateLimited}:              RateLimited,
+		&Error{Kind: Instrumental}:             Instrumental,
+		errors.New("other"):                    Unknown,
+		queryError("p", errors.New("timeout")): ProviderDown,
+	}
+	for err, kind := range cases {
+		if got := KindOf(err); got != kind {
+			t.Errorf("KindOf(%v) = %s expected %s", err, got, kind)
+		}
+	}
+	if errors.Is(&Error{Kind: RateLimited}, ErrLyricsNotFound) {
+		t.Errorf("RateLimited error shouldn't be ErrLyricsNotFound")
+	}
+}
+
+func TestChainFetch(t *testing.T) {
+	c := NewChain(
+		Provider{"down", &queryStub{err: &Error{Kind: ProviderDown}}},
+		Provider{"instrumental", &queryStub{err: &Error{Kind: Instrumental}}},
+		Provider{"v1", &stubFetcher{lyrics: "found"}},
+	)
+	r, err := c.Fetch(context.Background(), Query{Title: "T"})
+	if err != nil || r.Lyrics != "found" || r.Provider != "v1" {
+		t.Errorf("got %+v, %v", r, err)
+	}
+
+	c.Providers = c.Providers[:2]
+	_, err = c.Fetch(context.Background(), Query{Title: "T"})
+	if KindOf(err) != Instrumental {
+		t.Errorf("expected Instrumental error got %v", err)
+	}
+}
+
+func TestChainRaceCancels(t *testing.T) {
+	slow := &queryStub{result: &Result{Lyrics: "slow"}, delay: time.Minute, cancelled: make(chan bool, 1)}
+	c := NewChain(
+		Provider{"slow", slow},
+		Provider{"fast", &queryStub{result: &Result{Lyrics: "fast"}}},
+	)
+	c.Race = true
+	r, err := c.Fetch(context.Background(), Query{Title: "T"})
+	if err != nil || r.Provider != "fast" {
+		t.Fatalf("got %+v, %v", r, err)
+	}
+	select {
+	case <-slow.cancelled:
+	case <-time.After(time.Second):
+		t.Errorf("slower provider wasn't cancelled")
+	}
+}
+
+func TestNormalizerFetch(t *testing.T) {
+	f := mapFetcher{"Queen|Bohemian Rhapsody": "is this the real life"}
+	q := Query{Artists: []string{"Queen", "Other"}, Title: "Bohemian Rhapsody - Remastered 2011"}
+	r, err := NewNormalizer(f).Fetch(context.Background(), q)
+	if err != nil || r.Lyrics != "is this the real life" {
+		t.Errorf("got %+v, %v", r, err)
+	}
+}
+
+func TestCacheFetch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lyrics-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	synced, _ := ParseLRC(strings.NewReader("[00:01.00]la la\n"))
+	f := &queryStub{result: &Result{Lyrics: "la la", Synced: synced, Provider: "p", URL: "http://lyrics/1"}}
+	q := Query{Artists: []string{"A"}, Title: "T", SpotifyID: "id"}
+	c := NewCache(f, dir)
+	if _, err := c.Fetch(context.Background(), q); err != nil {
+		t.Fatal(err)
+	}
+
+	f.err, f.result = &Error{Kind: ProviderDown}, nil
+	restarted := NewCache(f, dir)
+	r, err := restarted.Fetch(context.Background(), Query{Title: "other title", SpotifyID: "id"})
+	if err != nil {
+		t.Fatalf("cached result not found by id: %v", err)
+	}
+	if r.URL != "http://lyrics/1" || r.Synced == nil || r.Synced.Lines[0].Time != time.Second {
+		t.Errorf("cached result lost data %+v", r)
+	}
+}
+
+func TestChainVersions(t *testing.T) {
+	live := &Result{Lyrics: "live", URL: "http://lyrics/live"}
+	c := NewChain(
+		Provider{"first", &queryStub{result: &Result{Lyrics: "album", URL: "http://lyrics/album", Versions: []*Result{live}}}},
+		Provider{"missing", &queryStub{err: &Error{Kind: NotFound}}},
+		Provider{"second", &queryStub{result: &Result{Lyrics: "other", URL: "http://other/1"}}},
+		Provider{"third", &queryStub{result: &Result{Lyrics: "never asked"}}},
+	)
+	c.Versions = 3
+	for _, race := range []bool{false, true} {
+		c.Race = race
+		r, err := c.Fetch(context.Background(), Query{Title: "T"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions := r.AllVersions()
+		if len(versions) != 3 {
+			t.Fatalf("race %v: got %d versions expected 3", race, len(versions))
+		}
+		if race {
+			continue
+		}
+		got := []string{}
+		for _, v := range versions {
+			got = append(got, v.Provider+":"+v.Lyrics)
+		}
+		if expected := "first:album first:live second:other"; strings.Join(got, " ") != expected {
+			t.Errorf("got versions %q expected %q", got, expected)
+		}
+	}
+
+	c.Versions, c.Race = 0, false
+	if r, _ := c.Fetch(context.Background(), Query{Title: "T"}); len(r.Versions) != 1 {
+		t.Errorf("versions of the first provider lost, got %+v", r.Versions)
+	}
+	if live.Provider != "" || live.Language != "" {
+		t.Errorf("version of the provider changed to %+v", live)
+	}
+}
+
+func TestCacheFetchVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lyrics-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	synced, _ := ParseLRC(strings.NewReader("[00:01.00]la la\n"))
+	version := &Result{Lyrics: "la la", Synced: synced, Provider: "p", URL: "http://lyrics/2", Confidence: 0.7}
+	f := &queryStub{result: &Result{Lyrics: "la", Provider: "p", URL: "http://lyrics/1", Confidence: 0.9, Versions: []*Result{version}}}
+	q := Query{Artists: []string{"A"}, Title: "T"}
+	NewCache(f, dir).Fetch(context.Background(), q)
+
+	f.err, f.result = &Error{Kind: ProviderDown}, nil
+	r, err := NewCache(f, dir).Fetch(context.Background(), q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Confidence != 0.9 || len(r.Versions) != 1 {
+		t.Fatalf("cached result lost the versions %+v", r)
+	}
+	if v := r.Versions[0]; v.URL != "http://lyrics/2" || v.Confidence != 0.7 || v.Synced == nil {
+		t.Errorf("cached version lost data %+v", v)
+	}
+}
+
+func TestSongInfoVersions(t *testing.T) {
+	f := &queryStub{result: &Result{
+		Lyrics:     "album",
+		Provider:   "a",
+		Confidence: 0.9,
+		Versions:   []*Result{{Lyrics: "live", Provider: "b", Confidence: 0.7}},
+	}}
+	song := SongInfo{Title: "T"}
+	if err := song.FetchContext(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+	if len(song.Versions) != 2 || song.Lyrics != "album" || song.Confidence != 0.9 {
+		t.Fatalf("got %+v", song)
+	}
+	song.SetVersion(1)
+	if song.Lyrics != "live" || song.Provider != "b" || song.Confidence != 0.7 || song.Version != 1 {
+		t.Errorf("version not set, got %+v", song)
+	}
+}
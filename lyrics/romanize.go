@@ -0,0 +1,249 @@
+package lyrics
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Romanizer transliterates the text into the Latin script.
+type Romanizer interface {
+	// Romanize returns the romanized text and whether
+	// the text was in the script the Romanizer knows.
+	Romanize(text string) (string, bool)
+}
+
+// RomanizerFunc is a function used as a Romanizer.
+type RomanizerFunc func(text string) (string, bool)
+
+// Romanize implements Romanizer.
+func (f RomanizerFunc) Romanize(text string) (string, bool) {
+	return f(text)
+}
+
+// Romanizers applies all of the romanizers in turn,
+// each to the output of the previous one,
+// so the text can mix the scripts.
+type Romanizers []Romanizer
+
+// Romanize implements Romanizer.
+func (rs Romanizers) Romanize(text string) (string, bool) {
+	romanized := false
+	for _, r := range rs {
+		if out, ok := r.Romanize(text); ok {
+			text, romanized = out, true
+		}
+	}
+	return text, romanized
+}
+
+// DefaultRomanizer romanizes the scripts with the
+// built-in transliteration tables.
+var DefaultRomanizer = Romanizers{Cyrillic, Greek, Hangul}
+
+// Romanize sets the Romanized form of the lyrics lines
+// which are not in the Latin script.
+func Romanize(l *Lyrics, r Romanizer) {
+	for i, line := range l.Lines {
+		if romanized, ok := r.Romanize(line.Text); ok && romanized != line.Text {
+			l.Lines[i].Romanized = romanized
+		}
+	}
+}
+
+// Romanized reports whether any of the lines is romanized.
+func (l *Lyrics) Romanized() bool {
+	for _, line := range l.Lines {
+		if line.Romanized != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// RomanTable is a Romanizer replacing the letters and
+// digraphs, up to two letters long, with their romanization.
+// Other characters are kept.
+type RomanTable map[string]string
+
+// newRomanTable returns RomanTable of the lowercase
+// letters with the capitalized ones added.
+func newRomanTable(lower map[string]string) RomanTable {
+	t := RomanTable{}
+	for from, to := range lower {
+		t[from] = to
+		t[capitalize(from)] = capitalize(to)
+		t[strings.ToUpper(from)] = capitalize(to)
+	}
+	return t
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// Romanize implements Romanizer. Capital letters
+// in the uppercase words are romanized all uppercase.
+func (t RomanTable) Romanize(text string) (string, bool) {
+	var b strings.Builder
+	romanized := false
+	prev := rune(0)
+	for len(text) > 0 {
+		r, first := utf8.DecodeRuneInString(text)
+		_, second := utf8.DecodeRuneInString(text[first:])
+		size := first + second
+		to, ok := t[text[:size]]
+		if !ok || second == 0 {
+			size = first
+			to, ok = t[text[:size]]
+		}
+		if !ok {
+			b.WriteRune(r)
+			text, prev = text[first:], r
+			continue
+		}
+		next, _ := utf8.DecodeRuneInString(text[size:])
+		if unicode.IsUpper(r) && (unicode.IsUpper(prev) || unicode.IsUpper(next)) {
+			to = strings.ToUpper(to)
+		}
+		b.WriteString(to)
+		text, prev, romanized = text[size:], r, true
+	}
+	return b.String(), romanized
+}
+
+// Cyrillic romanizes the Russian, Ukrainian, Belarusian
+// and Serbian Cyrillic close to the BGN/PCGN system.
+var Cyrillic = newRomanTable(map[string]string{
+	"а": "a", "б": "b", "в": "v", "г": "g", "д": "d", "е": "e",
+	"ё": "yo", "ж": "zh", "з": "z", "и": "i", "й": "y", "к": "k",
+	"л": "l", "м": "m", "н": "n", "о": "o", "п": "p", "р": "r",
+	"с": "s", "т": "t", "у": "u", "ф": "f", "х": "kh", "ц": "ts",
+	"ч": "ch", "ш": "sh", "щ": "shch", "ъ": "", "ы": "y", "ь": "",
+	"э": "e", "ю": "yu", "я": "ya",
+	// Ukrainian and Belarusian.
+	"є": "ye", "і": "i", "ї": "yi", "ґ": "g", "ў": "w",
+	// Serbian and Macedonian.
+	"ђ": "dj", "ј": "j", "љ": "lj", "њ": "nj", "ћ": "c", "џ": "dz",
+	"ѓ": "gj", "ќ": "kj", "ѕ": "dz",
+})
+
+// Greek romanizes the modern Greek close to the ELOT 743.
+var Greek = newRomanTable(map[string]string{
+	"α": "a", "β": "v", "γ": "g", "δ": "d", "ε": "e", "ζ": "z",
+	"η": "i", "θ": "th", "ι": "i", "κ": "k", "λ": "l", "μ": "m",
+	"ν": "n", "ξ": "x", "ο": "o", "π": "p", "ρ": "r", "σ": "s",
+	"ς": "s", "τ": "t", "υ": "y", "φ": "f", "χ": "ch", "ψ": "ps",
+	"ω": "o",
+	"ά": "a", "έ": "e", "ή": "i", "ί": "i", "ό": "o", "ύ": "y",
+	"ώ": "o", "ϊ": "i", "ϋ": "y", "ΐ": "i", "ΰ": "y",
+	// Digraphs.
+	"ου": "ou", "ού": "ou", "αυ": "av", "αύ": "av", "ευ": "ev",
+	"εύ": "ev", "γγ": "ng", "γκ": "gk", "γξ": "nx", "γχ": "nch",
+})
+
+// Hangul romanizes the Korean Hangul syllables
+// with the Revised Romanization. Only the final
+// consonants followed by a vowel are linked,
+// other sound changes are not applied.
+var Hangul Romanizer = RomanizerFunc(romanizeHangul)
+
+const (
+	hangulFirst = 0xAC00
+	hangulLast  = 0xD7A3
+	// hangulSilent is the index of the silent ㅇ initial.
+	hangulSilent = 11
+)
+
+var (
+	hangulInitials = []string{
+		"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s",
+		"ss", "", "j", "jj", "ch", "k", "t", "p", "h",
+	}
+	hangulVowels = []string{
+		"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa",
+		"wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i",
+	}
+	// hangulFinals are the final consonants
+	// at the end of the word or before a consonant.
+	hangulFinals = []string{
+		"", "k", "k", "k", "n", "n", "n", "t", "l", "k",
+		"m", "l", "l", "l", "p", "l", "m", "p", "p", "t",
+		"t", "ng", "t", "t", "k", "t", "p", "t",
+	}
+	// hangulLinked are the final consonants
+	// moved to the next syllable starting with a vowel.
+	hangulLinked = []string{
+		"", "g", "kk", "ks", "n", "nj", "n", "d", "r", "lg",
+		"lm", "lb", "ls", "lt", "lp", "r", "m", "b", "bs", "s",
+		"ss", "ng", "j", "ch", "k", "t", "p", "",
+	}
+)
+
+func romanizeHangul(text string) (string, bool) {
+	runes := []rune(text)
+	var b strings.Builder
+	romanized := false
+	for i, r := range runes {
+		if r < hangulFirst || r > hangulLast {
+			b.WriteRune(r)
+			continue
+		}
+		romanized = true
+		s := int(r - hangulFirst)
+		initial, vowel, final := s/588, s%588/28, s%28
+		b.WriteString(hangulInitials[initial])
+		b.WriteString(hangulVowels[vowel])
+		if i+1 < len(runes) && runes[i+1] >= hangulFirst && runes[i+1] <= hangulLast &&
+			int(runes[i+1]-hangulFirst)/588 == hangulSilent {
+			b.WriteString(hangulLinked[final])
+		} else {
+			b.WriteString(hangulFinals[final])
+		}
+	}
+	return b.String(), romanized
+}
+
+// CommandRomanizer is a Romanizer running the external
+// program, like kakasi for Japanese, with the text on
+// its standard input and the romanization read from
+// the standard output.
+type CommandRomanizer struct {
+	// Command is the program and its arguments.
+	Command []string
+	// Scripts are the scripts the program romanizes.
+	// It's run only for the text with letters in one of them,
+	// for any text if there are no Scripts.
+	Scripts []*unicode.RangeTable
+}
+
+// Romanize implements Romanizer. Errors of the
+// program are reported as not romanized text.
+func (c CommandRomanizer) Romanize(text string) (string, bool) {
+	if len(c.Command) == 0 {
+		return text, false
+	}
+	if len(c.Scripts) > 0 && strings.IndexFunc(text, func(r rune) bool {
+		return unicode.IsOneOf(c.Scripts, r)
+	}) < 0 {
+		return text, false
+	}
+	cmd := exec.Command(c.Command[0], c.Command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return text, false
+	}
+	return strings.TrimRight(out.String(), "\r\n"), true
+}
+
+// JapaneseScripts are the scripts of the Japanese
+// lyrics, for the CommandRomanizer.
+var JapaneseScripts = []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana, unicode.Han}
@@ -0,0 +1,54 @@
+package lyrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultRomanizer(t *testing.T) {
+	cases := map[string]string{
+		"Привет, как дела?":   "Privet, kak dela?",
+		"ЩУКА и Ёжик":         "SHCHUKA i Yozhik",
+		"Любов і їжа":         "Lyubov i yizha",
+		"Σ' αγαπώ":            "S' agapo",
+		"Ουρανός":             "Ouranos",
+		"Ευρώπη πολύ":         "Evropi poly",
+		"사랑해":                 "saranghae",
+		"안녕하세요":               "annyeonghaseyo",
+		"한국어":                 "hangugeo",
+		"Gangnam Style 강남스타일": "Gangnam Style gangnamseutail",
+	}
+	for text, expected := range cases {
+		got, ok := DefaultRomanizer.Romanize(text)
+		if !ok || got != expected {
+			t.Errorf("Romanize(%q) = %q, %v expected %q", text, got, ok, expected)
+		}
+	}
+	if _, ok := DefaultRomanizer.Romanize("Is this the real life?"); ok {
+		t.Errorf("latin text shouldn't be romanized")
+	}
+}
+
+func TestRomanizeLyrics(t *testing.T) {
+	l, _ := ParseLRC(strings.NewReader("[00:01.00]Привет\n[00:02.00]hello\n"))
+	Romanize(l, DefaultRomanizer)
+	if l.Lines[0].Romanized != "Privet" || l.Lines[1].Romanized != "" || !l.Romanized() {
+		t.Errorf("got lines %+v", l.Lines)
+	}
+}
+
+func TestCommandRomanizer(t *testing.T) {
+	r := CommandRomanizer{Command: []string{"tr", "a-z", "A-Z"}, Scripts: JapaneseScripts}
+	if _, ok := r.Romanize("latin only"); ok {
+		t.Errorf("command run for the text without japanese")
+	}
+	r.Scripts = nil
+	got, ok := r.Romanize("shout")
+	if !ok || got != "SHOUT" {
+		t.Errorf("got %q, %v", got, ok)
+	}
+	r.Command = []string{"no-such-romanizer-command"}
+	if _, ok = r.Romanize("text"); ok {
+		t.Errorf("failed command reported as romanized")
+	}
+}
@@ -0,0 +1,203 @@
+package lyrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// TekstowoURL is the default TekstowoFetcher base url.
+const TekstowoURL = "https://www.tekstowo.pl"
+
+// TekstowoFetcher is a QueryFetcher and Fetcher scraping lyrics
+// from tekstowo.pl. Its zero value is ready to use.
+//
+// The song is searched for by the artist and title
+// and the result best matching them is picked
+// with the Matcher. Then the lyrics are extracted
+// from the song page.
+type TekstowoFetcher struct {
+	// BaseURL of the site, TekstowoURL if empty.
+	BaseURL string
+	// Client makes the requests,
+	// http.DefaultClient if nil.
+	Client *http.Client
+	// Matcher picks the search result,
+	// DefaultMatcher if nil.
+	Matcher *Matcher
+}
+
+// tekstowoName is the provider name in the errors and results.
+const tekstowoName = "tekstowo"
+
+// Fetch implements QueryFetcher.
+func (f TekstowoFetcher) Fetch(ctx context.Context, q Query) (*Result, error) {
+	doc, pageURL, confidence, err := f.songPage(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	lyrics := tekstowoLyrics(doc)
+	if lyrics == "" {
+		return nil, &Error{Kind: NotFound, Provider: tekstowoName}
+	}
+	r := newResult(tekstowoName, lyrics, nil)
+	r.URL = pageURL
+	r.Confidence = confidence
+	return r, nil
+}
+
+// songPage returns the parsed page of the search result
+// best matching the query, its url and the match confidence.
+func (f TekstowoFetcher) songPage(ctx context.Context, q Query) (*html.Node, string, float64, error) {
+	hits, err := f.search(ctx, q.Artist(), q.Title)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	hit, score, err := f.matcher().Best(q.song(), hits)
+	if err != nil {
+		return nil, "", 0, &Error{Kind: NotFound, Provider: tekstowoName, Err: err}
+	}
+	doc, err := f.get(ctx, hit.Ref)
+	return doc, hit.Ref, score, err
+}
+
+// FetchLyrics implements Fetcher.
+func (f TekstowoFetcher) FetchLyrics(author, title string) (string, error) {
+	r, err := f.Fetch(context.Background(), Query{Artists: []string{author}, Title: title})
+	if err != nil {
+		return "", fetcherError(err)
+	}
+	return r.Lyrics, nil
+}
+
+func (f TekstowoFetcher) baseURL() string {
+	if f.BaseURL != "" {
+		return strings.TrimRight(f.BaseURL, "/")
+	}
+	return TekstowoURL
+}
+
+func (f TekstowoFetcher) matcher() Matcher {
+	if f.Matcher != nil {
+		return *f.Matcher
+	}
+	return DefaultMatcher
+}
+
+// search returns the songs found
+// by the sites search.
+func (f TekstowoFetcher) search(ctx context.Context, author, title string) ([]Hit, error) {
+	path := fmt.Sprintf("/szukaj,wykonawca,%s,tytul,%s.html",
+		url.QueryEscape(author), url.QueryEscape(title))
+	doc, err := f.get(ctx, f.baseURL()+path)
+	if err != nil {
+		return nil, err
+	}
+	return tekstowoHits(doc, f.baseURL()), nil
+}
+
+// get fetches and parses the page.
+// Failures are reported as the *Error.
+func (f TekstowoFetcher) get(ctx context.Context, pageURL string) (*html.Node, error) {
+	return getPage(ctx, f.Client, tekstowoName, pageURL)
+}
+
+// tekstowoHits returns the song links of the search results
+// page. Links titles are in the "Artist - Title" format.
+func tekstowoHits(doc *html.Node, base string) []Hit {
+	hits := []Hit{}
+	seen := map[string]bool{}
+	for _, box := range findAll(doc, func(n *html.Node) bool { return hasClass(n, "box-przeboje") }) {
+		for _, a := range findAll(box, isElement("a")) {
+			href := attr(a, "href")
+			if !strings.HasPrefix(href, "/piosenka,") || seen[href] {
+				continue
+			}
+			seen[href] = true
+			name := attr(a, "title")
+			if name == "" {
+				name = textContent(a)
+			}
+			artist, title := name, ""
+			if i := strings.Index(name, " - "); i >= 0 {
+				artist, title = name[:i], name[i+3:]
+			}
+			hits = append(hits, Hit{
+				Artist: strings.TrimSpace(artist),
+				Title:  strings.TrimSpace(title),
+				Ref:    base + href,
+			})
+		}
+	}
+	return hits
+}
+
+// tekstowoLyrics extracts the lyrics from the song page,
+// empty if the page has none.
+func tekstowoLyrics(doc *html.Node) string {
+	text := find(doc, func(n *html.Node) bool { return hasClass(n, "song-text") })
+	if text == nil {
+		return ""
+	}
+	return tekstowoText(text)
+}
+
+// tekstowoTranslation extracts the translation of the
+// lyrics from the song page, empty if the page has none.
+func tekstowoTranslation(doc *html.Node) string {
+	box := find(doc, func(n *html.Node) bool {
+		return hasClass(n, "tlumaczenie") || (n.Type == html.ElementNode && attr(n, "id") == "translation")
+	})
+	if box == nil {
+		return ""
+	}
+	return tekstowoText(box)
+}
+
+// tekstowoText returns the text of the lyrics or
+// translation box, one line per line of the song.
+func tekstowoText(box *html.Node) string {
+	text := box
+	// Newer pages wrap the text in the inner-text div,
+	// older ones put it right after the heading.
+	if inner := find(box, func(n *html.Node) bool { return hasClass(n, "inner-text") }); inner != nil {
+		text = inner
+	}
+	sb := strings.Builder{}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			sb.WriteString(strings.Trim(n.Data, "\r\n"))
+			return
+		case n.Type == html.ElementNode && n.Data == "br":
+			sb.WriteString("\n")
+			return
+		case n.Type == html.ElementNode && skipInLyrics(n):
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(text)
+	lines := strings.Split(sb.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// skipInLyrics reports whether the element of the song
+// text box is not a part of the lyrics.
+func skipInLyrics(n *html.Node) bool {
+	switch n.Data {
+	case "h2", "script", "style", "a", "button":
+		return true
+	}
+	return hasClass(n, "adv-pion") || hasClass(n, "left-corner")
+}
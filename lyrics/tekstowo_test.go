@@ -0,0 +1,85 @@
+package lyrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tekstowoServer serves the saved pages, search results
+// for any query and the song page of the Queen's
+// Bohemian Rhapsody.
+func tekstowoServer(song string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/szukaj,"):
+			http.ServeFile(w, r, filepath.Join("testdata", "tekstowo_search.html"))
+		case r.URL.Path == "/piosenka,queen,bohemian_rhapsody.html":
+			http.ServeFile(w, r, filepath.Join("testdata", song))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestTekstowoFetcher(t *testing.T) {
+	server := tekstowoServer("tekstowo_song.html")
+	defer server.Close()
+	f := TekstowoFetcher{BaseURL: server.URL}
+
+	lyrics, err := f.FetchLyrics("Queen", "Bohemian Rhapsody - Remastered 2011")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	expected := "Is this the real life?\n" +
+		"Is this just fantasy?\n" +
+		"Caught in a landslide,\n" +
+		"No escape from reality.\n" +
+		"\n" +
+		"Open your eyes,\n" +
+		"Look up to the skies and see,\n" +
+		"I'm just a poor boy, I need no sympathy"
+	if lyrics != expected {
+		t.Errorf("got lyrics\n%q\nexpected\n%q", lyrics, expected)
+	}
+}
+
+func TestTekstowoFetcherRejectsOtherSongs(t *testing.T) {
+	server := tekstowoServer("tekstowo_song.html")
+	defer server.Close()
+	f := TekstowoFetcher{BaseURL: server.URL}
+
+	if _, err := f.FetchLyrics("Metallica", "One"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestTekstowoOldSongPage(t *testing.T) {
+	server := tekstowoServer("tekstowo_song_old.html")
+	defer server.Close()
+	f := TekstowoFetcher{BaseURL: server.URL}
+
+	lyrics, err := f.FetchLyrics("Queen", "Bohemian Rhapsody")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	expected := "Brosandi\nHendumst í hringi\nHöldumst í hendur"
+	if lyrics != expected {
+		t.Errorf("got lyrics %q expected %q", lyrics, expected)
+	}
+}
+
+func TestTekstowoServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	f := TekstowoFetcher{BaseURL: server.URL}
+
+	_, err := f.FetchLyrics("Queen", "Bohemian Rhapsody")
+	if err == nil || err == ErrLyricsNotFound {
+		t.Errorf("expected the server error got %v", err)
+	}
+}
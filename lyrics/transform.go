@@ -0,0 +1,308 @@
+package lyrics
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Transform cleans up the lyrics lines after they are
+// fetched. The line timings are kept, so it works for
+// both the plain and the synced lyrics.
+type Transform interface {
+	// Apply returns the transformed lines. It can
+	// change the lines in place and drop them.
+	Apply(lines []Line) []Line
+}
+
+// TransformFunc is a function used as a Transform.
+type TransformFunc func(lines []Line) []Line
+
+// Apply implements Transform.
+func (f TransformFunc) Apply(lines []Line) []Line {
+	return f(lines)
+}
+
+// LineTransform is a Transform changing each line on its
+// own. It returns the new text and whether to keep the line.
+type LineTransform func(text string) (string, bool)
+
+// Apply implements Transform.
+func (f LineTransform) Apply(lines []Line) []Line {
+	kept := lines[:0]
+	for _, line := range lines {
+		text, ok := f(line.Text)
+		if !ok {
+			continue
+		}
+		line.Text = text
+		kept = append(kept, line)
+	}
+	return kept
+}
+
+// DecodeEntities decodes the HTML entities, like &amp;
+// or &#39;, left in the scraped lyrics.
+var DecodeEntities = LineTransform(func(text string) (string, bool) {
+	return html.UnescapeString(text), true
+})
+
+// invisibleRe matches the zero width characters.
+var invisibleRe = regexp.MustCompile("[\u200b\u200c\u200d\u2060\ufeff]")
+
+// NormalizeWhitespace trims the lines, turns all of the
+// spaces into single plain ones, collapses the runs of
+// blank lines into one and drops the blank lines
+// at the start and the end.
+var NormalizeWhitespace = TransformFunc(func(lines []Line) []Line {
+	kept := lines[:0]
+	for _, line := range lines {
+		line.Text = strings.Join(strings.FieldsFunc(invisibleRe.ReplaceAllString(line.Text, ""), unicode.IsSpace), " ")
+		if line.Text == "" && (len(kept) == 0 || kept[len(kept)-1].Text == "") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	for len(kept) > 0 && kept[len(kept)-1].Text == "" {
+		kept = kept[:len(kept)-1]
+	}
+	return kept
+})
+
+// headerRe matches the section headers like "[Chorus]",
+// "[Verse 1: Freddie Mercury]" or "(Refren)" on their own line.
+var headerRe = regexp.MustCompile(`(?i)^\s*(?:\[[^\]]*\]|\((?:intro|verse|pre-chorus|chorus|bridge|hook|outro|refren|zwrotka|ref\.?)[^)]*\))\s*:?\s*$`)
+
+// StripHeaders drops the section header lines.
+var StripHeaders = LineTransform(func(text string) (string, bool) {
+	return text, !headerRe.MatchString(text)
+})
+
+var (
+	// junkLineRes match the ad and credits lines
+	// the providers put between the lyrics.
+	junkLineRes = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^\s*you might also like\s*$`),
+		regexp.MustCompile(`(?i)^\s*see .* live\s*get tickets as low as`),
+		regexp.MustCompile(`(?i)^\s*\d+\s*contributors?`),
+		regexp.MustCompile(`(?i)^\s*(?:written by|lyrics by|music by|songwriters?|composers?|producers?|produced by)\s*:`),
+		regexp.MustCompile(`(?i)^\s*(?:source|lyrics licensed by|lyrics powered by)\s*:?\s*(?:lyricfind|musixmatch|www\.)`),
+		regexp.MustCompile(`(?i)^\s*(?:tekst|lyrics) (?:dodał|added by)\b`),
+	}
+	// embedRe matches the "Embed" suffix with the
+	// optional pageviews count the last line gets.
+	embedRe = regexp.MustCompile(`\d*\s*Embed\s*$`)
+)
+
+// StripJunk drops the ad and credits lines and
+// the "Embed" suffixes scraped with the lyrics.
+var StripJunk = LineTransform(func(text string) (string, bool) {
+	for _, re := range junkLineRes {
+		if re.MatchString(text) {
+			return "", false
+		}
+	}
+	stripped := embedRe.ReplaceAllString(text, "")
+	return stripped, stripped != "" || text == ""
+})
+
+// DefaultProfanities are the words masked by
+// MaskProfanity if no others are given.
+var DefaultProfanities = []string{
+	"fuck", "fucking", "fucked", "shit", "bitch", "bitches",
+	"motherfucker", "asshole", "cunt", "dick",
+}
+
+// MaskProfanity returns the Transform replacing all but
+// the first letter of the words with asterisks.
+// Words are matched case insensitively and whole.
+func MaskProfanity(words ...string) Transform {
+	if len(words) == 0 {
+		words = DefaultProfanities
+	}
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = regexp.QuoteMeta(w)
+	}
+	re := regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	return LineTransform(func(text string) (string, bool) {
+		return re.ReplaceAllStringFunc(text, func(word string) string {
+			runes := []rune(word)
+			return string(runes[0]) + strings.Repeat("*", len(runes)-1)
+		}), true
+	})
+}
+
+// RegexRule is a Transform replacing the matches of the
+// Pattern with the Replacement, which can refer to the
+// submatches like regexp.ReplaceAllString does.
+// Lines left empty by the rule are dropped.
+type RegexRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Apply implements Transform.
+func (r RegexRule) Apply(lines []Line) []Line {
+	return LineTransform(func(text string) (string, bool) {
+		replaced := r.Pattern.ReplaceAllString(text, r.Replacement)
+		return replaced, replaced != "" || text == ""
+	}).Apply(lines)
+}
+
+// Pipeline applies the transforms in order.
+type Pipeline []Transform
+
+// DefaultPipeline decodes the entities, strips
+// the junk and normalizes the whitespace.
+var DefaultPipeline = Pipeline{DecodeEntities, StripJunk, NormalizeWhitespace}
+
+// Apply implements Transform. Word timings of the
+// lines which no longer have the timed words are dropped.
+func (p Pipeline) Apply(lines []Line) []Line {
+	for _, t := range p {
+		lines = t.Apply(lines)
+	}
+	for i, line := range lines {
+		if len(line.Words) == 0 {
+			continue
+		}
+		words := make([]string, len(line.Words))
+		for j, w := range line.Words {
+			words[j] = w.Text
+		}
+		if !strings.Contains(strings.Join(strings.Fields(line.Text), " "), strings.Join(words, " ")) {
+			lines[i].Words = nil
+		}
+	}
+	return lines
+}
+
+// Lyrics returns the transformed copy of the lyrics.
+func (p Pipeline) Lyrics(l *Lyrics) *Lyrics {
+	if l == nil {
+		return nil
+	}
+	transformed := *l
+	transformed.Lines = p.Apply(append([]Line(nil), l.Lines...))
+	return &transformed
+}
+
+// Text returns the transformed plain lyrics.
+func (p Pipeline) Text(text string) string {
+	if text == "" {
+		return ""
+	}
+	return p.Lyrics(PlainLyrics(text)).Text()
+}
+
+// Postprocessor is a Fetcher and QueryFetcher decorator
+// applying the Pipeline to the lyrics fetched by the
+// wrapped Fetcher.
+type Postprocessor struct {
+	Fetcher  Fetcher
+	Pipeline Pipeline
+}
+
+// NewPostprocessor returns Postprocessor
+// applying the DefaultPipeline.
+func NewPostprocessor(f Fetcher) *Postprocessor {
+	return &Postprocessor{Fetcher: f, Pipeline: DefaultPipeline}
+}
+
+// Fetch implements QueryFetcher. The wrapped Fetcher is
+// adapted with Adapt if it's not a QueryFetcher.
+func (p *Postprocessor) Fetch(ctx context.Context, q Query) (*Result, error) {
+	r, err := Adapt(p.Fetcher).Fetch(ctx, q)
+	if err != nil {
+		return r, err
+	}
+	return p.result(r), nil
+}
+
+// result returns the copy of the Result
+// with all of the versions transformed.
+func (p *Postprocessor) result(r *Result) *Result {
+	transformed := *r
+	transformed.Synced = p.Pipeline.Lyrics(r.Synced)
+	if transformed.Synced != nil {
+		transformed.Lyrics = transformed.Synced.Text()
+	} else {
+		transformed.Lyrics = p.Pipeline.Text(r.Lyrics)
+	}
+	transformed.Versions = nil
+	for _, v := range r.Versions {
+		transformed.Versions = append(transformed.Versions, p.result(v))
+	}
+	return &transformed
+}
+
+// FetchLyrics implements Fetcher.
+func (p *Postprocessor) FetchLyrics(author, title string) (string, error) {
+	lyrics, _, err := p.FetchLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchLyricsFrom fetches the lyrics and returns
+// name of the provider which found them, if the
+// wrapped Fetcher reports it.
+func (p *Postprocessor) FetchLyricsFrom(author, title string) (string, string, error) {
+	var lyrics, provider string
+	var err error
+	if sf, ok := p.Fetcher.(sourceFetcher); ok {
+		lyrics, provider, err = sf.FetchLyricsFrom(author, title)
+	} else {
+		lyrics, err = p.Fetcher.FetchLyrics(author, title)
+	}
+	return p.Pipeline.Text(lyrics), provider, err
+}
+
+// FetchSyncedLyrics implements SyncedFetcher.
+// If the wrapped Fetcher is not a SyncedFetcher
+// ErrLyricsNotFound is returned.
+func (p *Postprocessor) FetchSyncedLyrics(author, title string) (*Lyrics, error) {
+	lyrics, _, err := p.FetchSyncedLyricsFrom(author, title)
+	return lyrics, err
+}
+
+// FetchSyncedLyricsFrom is FetchLyricsFrom for
+// the synced lyrics.
+func (p *Postprocessor) FetchSyncedLyricsFrom(author, title string) (*Lyrics, string, error) {
+	sf, ok := p.Fetcher.(SyncedFetcher)
+	if !ok {
+		return nil, "", ErrLyricsNotFound
+	}
+	var lyrics *Lyrics
+	var provider string
+	var err error
+	if ssf, ok := p.Fetcher.(syncedSourceFetcher); ok {
+		lyrics, provider, err = ssf.FetchSyncedLyricsFrom(author, title)
+	} else {
+		lyrics, err = sf.FetchSyncedLyrics(author, title)
+	}
+	return p.Pipeline.Lyrics(lyrics), provider, err
+}
+
+// FetchTrackLyrics looks the lyrics up by the spotify
+// track id too, if the wrapped Fetcher can, like the Cache.
+func (p *Postprocessor) FetchTrackLyrics(id, author, title string) (string, string, error) {
+	tf, ok := p.Fetcher.(trackFetcher)
+	if !ok {
+		return p.FetchLyricsFrom(author, title)
+	}
+	lyrics, provider, err := tf.FetchTrackLyrics(id, author, title)
+	return p.Pipeline.Text(lyrics), provider, err
+}
+
+// FetchTrackSyncedLyrics is FetchTrackLyrics
+// for the synced lyrics.
+func (p *Postprocessor) FetchTrackSyncedLyrics(id, author, title string) (*Lyrics, string, error) {
+	tf, ok := p.Fetcher.(trackFetcher)
+	if !ok {
+		return p.FetchSyncedLyricsFrom(author, title)
+	}
+	lyrics, provider, err := tf.FetchTrackSyncedLyrics(id, author, title)
+	return p.Pipeline.Lyrics(lyrics), provider, err
+}
@@ -0,0 +1,116 @@
+package lyrics
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransforms(t *testing.T) {
+	cases := []struct {
+		name      string
+		transform Transform
+		text      string
+		expected  string
+	}{
+		{"entities", DecodeEntities, "Rock &amp; roll\nDon&#39;t stop", "Rock & roll\nDon't stop"},
+		{"whitespace", NormalizeWhitespace, "\n  Is this\tthe  real life?\u200b \n\n\n\nIs this just fantasy?\n\n", "Is this the real life?\n\nIs this just fantasy?"},
+		{"headers", StripHeaders, "[Verse 1: Freddie Mercury]\nMama\n(Chorus)\n(ooh)\n[x2]", "Mama\n(ooh)"},
+		{"junk", StripJunk, "12 ContributorsBohemian Rhapsody Lyrics\nMama\nYou might also like\nWritten by: Freddie Mercury\nAnyway the wind blows42Embed", "Mama\nAnyway the wind blows"},
+		{"junk keeps blank lines", StripJunk, "one\n\ntwo", "one\n\ntwo"},
+		{"profanity", MaskProfanity(), "Shit, what the FUCK\nshitake", "S***, what the F***\nshitake"},
+		{"custom profanity", MaskProfanity("kurwa"), "Kurwa mać", "K**** mać"},
+		{"rule", RegexRule{regexp.MustCompile(`\s*\(x\d\)$`), ""}, "Mama (x2)\n(x2)", "Mama"},
+		{"rule submatches", RegexRule{regexp.MustCompile(`(\w+)-(\w+)`), "$2 $1"}, "wind-blows", "blows wind"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := (Pipeline{c.transform}).Text(c.text); got != c.expected {
+				t.Errorf("got %q expected %q", got, c.expected)
+			}
+		})
+	}
+}
+
+func TestDefaultPipeline(t *testing.T) {
+	text := "3 Contributors\n\nIs this the real life?&nbsp;\n\n\nYou might also like\nIs this just fantasy?Embed\n"
+	expected := "Is this the real life?\n\nIs this just fantasy?"
+	if got := DefaultPipeline.Text(text); got != expected {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+}
+
+func TestPipelineKeepsTiming(t *testing.T) {
+	l := &Lyrics{Lines: []Line{
+		{Time: time.Second, Text: "[Chorus]"},
+		{Time: 2 * time.Second, Text: "Rock  &amp; roll", Words: []Word{{2 * time.Second, "Rock"}, {3 * time.Second, "&amp;"}}},
+		{Time: 4 * time.Second, Text: "all  night", Words: []Word{{4 * time.Second, "all"}, {5 * time.Second, "night"}}},
+	}}
+	got := Pipeline{StripHeaders, DecodeEntities, NormalizeWhitespace}.Lyrics(l)
+	if len(got.Lines) != 2 || got.Lines[0].Time != 2*time.Second || got.Lines[1].Time != 4*time.Second {
+		t.Fatalf("got lines %+v", got.Lines)
+	}
+	if got.Lines[0].Text != "Rock & roll" || got.Lines[0].Words != nil {
+		t.Errorf("changed line should lose the word timings, got %+v", got.Lines[0])
+	}
+	if got.Lines[1].Text != "all night" || len(got.Lines[1].Words) != 2 {
+		t.Errorf("word timings of the unchanged words dropped, got %+v", got.Lines[1])
+	}
+	if len(l.Lines) != 3 || l.Lines[1].Text != "Rock  &amp; roll" {
+		t.Errorf("original lyrics changed to %+v", l.Lines)
+	}
+}
+
+func TestPostprocessor(t *testing.T) {
+	synced, _ := ParseLRC(strings.NewReader("[00:01.00]Mama&#39;s\n[00:02.00]\n[00:03.00]\n[00:04.00]  ooh  \n"))
+	stub := &queryStub{result: &Result{Lyrics: "raw", Synced: synced, Provider: "lrclib"}}
+	p := NewPostprocessor(stub)
+
+	r, err := p.Fetch(context.Background(), Query{Artists: []string{"Queen"}, Title: "T"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Lyrics != "Mama's\n\nooh" || r.Provider != "lrclib" || len(r.Synced.Lines) != 3 {
+		t.Errorf("got %q from %q synced %+v", r.Lyrics, r.Provider, r.Synced.Lines)
+	}
+	if stub.result.Synced.Lines[0].Text != "Mama&#39;s" {
+		t.Errorf("fetched lyrics changed to %+v", stub.result.Synced.Lines)
+	}
+
+	lyrics, err := NewPostprocessor(&stubFetcher{lyrics: "Mama\n\n\nooh42Embed"}).FetchLyrics("Queen", "T")
+	if err != nil || lyrics != "Mama\n\nooh" {
+		t.Errorf("got %q, %v", lyrics, err)
+	}
+	if _, err = NewPostprocessor(&stubFetcher{}).FetchSyncedLyrics("Queen", "T"); err != ErrLyricsNotFound {
+		t.Errorf("expected ErrLyricsNotFound got %v", err)
+	}
+}
+
+func TestPostprocessorInsideOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "overrides")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stub := &queryStub{result: &Result{Lyrics: "Mama&#39;s", Provider: "lrclib"}}
+	o := NewOverrides(NewPostprocessor(stub), dir)
+	saved := "[Chorus: Freddie Mercury]\nMama&#39;s   boy\n\n\nooh"
+	if err = o.Save("id", PlainLyrics(saved)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := o.Fetch(context.Background(), Query{Title: "T", SpotifyID: "id"})
+	if err != nil || r.Lyrics != saved {
+		t.Errorf("saved lyrics changed to %q, %v", r.Lyrics, err)
+	}
+	if lyrics, _, _ := o.FetchTrackLyrics("id", "Queen", "T"); lyrics != saved {
+		t.Errorf("saved lyrics changed to %q", lyrics)
+	}
+	if r, _ = o.Fetch(context.Background(), Query{Title: "T", SpotifyID: "other"}); r.Lyrics != "Mama's" {
+		t.Errorf("fetched lyrics not cleaned up, got %q", r.Lyrics)
+	}
+}
@@ -0,0 +1,342 @@
+package lyrics
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoTranslation is returned by the Translator which
+// can't translate the song into the target language.
+var ErrNoTranslation = errors.New("no translation of the lyrics")
+
+// Translator translates the lyrics of the song.
+type Translator interface {
+	// Translate returns the translation of the lines into
+	// the target language, given as the ISO 639-1 code.
+	// There is one translated line per line, empty
+	// for the lines which are not translated.
+	Translate(ctx context.Context, q Query, lines []string, target string) ([]string, error)
+}
+
+// Translate sets the Translation of the lyrics lines,
+// so the translation keeps the lines timing.
+func Translate(ctx context.Context, t Translator, q Query, l *Lyrics, target string) error {
+	lines := make([]string, len(l.Lines))
+	for i, line := range l.Lines {
+		lines[i] = line.Text
+	}
+	translated, err := t.Translate(ctx, q, lines, target)
+	if err != nil {
+		return err
+	}
+	for i := range l.Lines {
+		if i < len(translated) {
+			l.Lines[i].Translation = translated[i]
+		}
+	}
+	return nil
+}
+
+// Translated reports whether any of the lines is translated.
+func (l *Lyrics) Translated() bool {
+	for _, line := range l.Lines {
+		if line.Translation != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// TranslatorChain asks the translators in order
+// until one of them translates the lyrics.
+type TranslatorChain []Translator
+
+// Translate implements Translator. Returns the
+// last error if none of the translators succeeded.
+func (c TranslatorChain) Translate(ctx context.Context, q Query, lines []string, target string) ([]string, error) {
+	err := ErrNoTranslation
+	for _, t := range c {
+		var translated []string
+		translated, err = t.Translate(ctx, q, lines, target)
+		if err == nil {
+			return translated, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != ErrNoTranslation {
+			log.Printf("Translator failed: %s", err)
+		}
+	}
+	return nil, err
+}
+
+// alignLines assigns the translated lines to the original
+// ones. With as many lines on both sides they are matched
+// by the index, so the lines left untranslated stay in place.
+// Otherwise they are assigned in order, skipping the empty ones
+// on both sides, as the translations often differ in the blank lines.
+func alignLines(original, translated []string) []string {
+	aligned := make([]string, len(original))
+	if len(translated) == len(original) {
+		for i, line := range translated {
+			aligned[i] = strings.TrimSpace(line)
+		}
+		return aligned
+	}
+	next := 0
+	for i, line := range original {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		for next < len(translated) && strings.TrimSpace(translated[next]) == "" {
+			next++
+		}
+		if next == len(translated) {
+			break
+		}
+		aligned[i] = strings.TrimSpace(translated[next])
+		next++
+	}
+	return aligned
+}
+
+// TekstowoTranslator is a Translator scraping the
+// translations from the tekstowo.pl song pages.
+// Translations there are into Polish only.
+type TekstowoTranslator struct {
+	Fetcher TekstowoFetcher
+}
+
+// Translate implements Translator.
+func (t TekstowoTranslator) Translate(ctx context.Context, q Query, lines []string, target string) ([]string, error) {
+	if target != "pl" {
+		return nil, ErrNoTranslation
+	}
+	doc, _, _, err := t.Fetcher.songPage(ctx, q)
+	if KindOf(err) == NotFound {
+		return nil, ErrNoTranslation
+	}
+	if err != nil {
+		return nil, err
+	}
+	translation := tekstowoTranslation(doc)
+	if translation == "" {
+		return nil, ErrNoTranslation
+	}
+	return alignLines(lines, strings.Split(translation, "\n")), nil
+}
+
+// LibreTranslateURL is the default LibreTranslate base url.
+const LibreTranslateURL = "https://libretranslate.com"
+
+// LibreTranslate is a Translator using the
+// LibreTranslate compatible translation api.
+type LibreTranslate struct {
+	// BaseURL of the api, LibreTranslateURL if empty.
+	BaseURL string
+	// APIKey is sent if it's set.
+	APIKey string
+	// Source is the language of the lyrics,
+	// detected by the api if empty.
+	Source string
+	// Client makes the requests,
+	// http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// libreTranslateName is the translator name in the errors.
+const libreTranslateName = "libretranslate"
+
+type libreTranslateRequest struct {
+	Q      []string `json:"q"`
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Format string   `json:"format"`
+	APIKey string   `json:"api_key,omitempty"`
+}
+
+type libreTranslateResponse struct {
+	TranslatedText []string `json:"translatedText"`
+}
+
+// Translate implements Translator. Only the
+// not empty lines are sent to the api, the api
+// returns one translated text per each of them.
+func (t LibreTranslate) Translate(ctx context.Context, q Query, lines []string, target string) ([]string, error) {
+	texts, indices := []string{}, []int{}
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			texts = append(texts, line)
+			indices = append(indices, i)
+		}
+	}
+	if len(texts) == 0 {
+		return make([]string, len(lines)), nil
+	}
+	source := t.Source
+	if source == "" {
+		source = "auto"
+	}
+	body, err := json.Marshal(libreTranslateRequest{
+		Q:      texts,
+		Source: source,
+		Target: target,
+		Format: "text",
+		APIKey: t.APIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	base := LibreTranslateURL
+	if t.BaseURL != "" {
+		base = strings.TrimRight(t.BaseURL, "/")
+	}
+	req, err := http.NewRequest("POST", base+"/translate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &Error{Kind: ProviderDown, Provider: libreTranslateName, Err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(libreTranslateName, resp)
+	}
+	var result libreTranslateResponse
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	translated := make([]string, len(lines))
+	for k, text := range result.TranslatedText {
+		if k < len(indices) {
+			translated[indices[k]] = strings.TrimSpace(text)
+		}
+	}
+	return translated, nil
+}
+
+// TranslationCache is a Translator decorator caching
+// the translations. If Dir is set they are stored
+// on disk as well. ErrNoTranslation results are
+// remembered only in memory.
+type TranslationCache struct {
+	Translator Translator
+	Dir        string
+
+	mu      sync.Mutex
+	entries map[string][]string
+}
+
+// NewTranslationCache returns TranslationCache
+// around the Translator storing translations in the dir.
+func NewTranslationCache(t Translator, dir string) *TranslationCache {
+	return &TranslationCache{Translator: t, Dir: dir}
+}
+
+type translationEntry struct {
+	Artist string
+	Title  string
+	Target string
+	Lines  []string
+}
+
+// Translate implements Translator.
+func (c *TranslationCache) Translate(ctx context.Context, q Query, lines []string, target string) ([]string, error) {
+	key := translationKey(q, lines, target)
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string][]string{}
+	}
+	translated, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		if translated == nil {
+			return nil, ErrNoTranslation
+		}
+		return translated, nil
+	}
+	if e, err := c.read(key); err == nil {
+		c.remember(key, e.Lines)
+		return e.Lines, nil
+	}
+	translated, err := c.Translator.Translate(ctx, q, lines, target)
+	switch {
+	case err == ErrNoTranslation:
+		c.remember(key, nil)
+	case err == nil:
+		c.remember(key, translated)
+		e := translationEntry{Artist: q.Artist(), Title: q.Title, Target: target, Lines: translated}
+		if werr := c.write(key, e); werr != nil {
+			log.Printf("Could not store translation in cache: %s", werr)
+		}
+	}
+	return translated, err
+}
+
+// translationKey identifies the translation of the
+// lyrics, so lyrics from other providers are
+// translated again.
+func translationKey(q Query, lines []string, target string) string {
+	sum := sha1.Sum([]byte(target + "|" + Fold(q.Artist()) + "|" + Fold(q.Title) + "|" + strings.Join(lines, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *TranslationCache) remember(key string, lines []string) {
+	c.mu.Lock()
+	c.entries[key] = lines
+	c.mu.Unlock()
+}
+
+func (c *TranslationCache) read(key string) (*translationEntry, error) {
+	if c.Dir == "" {
+		return nil, os.ErrNotExist
+	}
+	data, err := ioutil.ReadFile(filepath.Join(c.Dir, key+".json"))
+	if err != nil {
+		return nil, err
+	}
+	var e translationEntry
+	if err = json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (c *TranslationCache) write(key string, e translationEntry) error {
+	if c.Dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(c.Dir, key+".json")
+	if err = ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,180 @@
+package lyrics
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// translatorStub translates the lines to upper case
+// and counts the calls.
+type translatorStub struct {
+	err   error
+	calls int
+}
+
+func (t *translatorStub) Translate(ctx context.Context, q Query, lines []string, target string) ([]string, error) {
+	t.calls++
+	if t.err != nil {
+		return nil, t.err
+	}
+	translated := make([]string, len(lines))
+	for i, line := range lines {
+		translated[i] = strings.ToUpper(line)
+	}
+	return translated, nil
+}
+
+func TestAlignLines(t *testing.T) {
+	original := []string{"one", "", "two", "three"}
+	translated := []string{"", "jeden", "dwa", "", ""}
+	expected := []string{"jeden", "", "dwa", ""}
+	if got := alignLines(original, translated); !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+
+	translated = []string{"", "", "dwa", "trzy"}
+	expected = []string{"", "", "dwa", "trzy"}
+	if got := alignLines(original, translated); !reflect.DeepEqual(got, expected) {
+		t.Errorf("untranslated line shifted the rest, got %q expected %q", got, expected)
+	}
+}
+
+func TestTranslateKeepsTiming(t *testing.T) {
+	l, _ := ParseLRC(strings.NewReader("[00:01.00]la la\n[00:02.00]\n[00:03.00]na na\n"))
+	if err := Translate(context.Background(), &translatorStub{}, Query{}, l, "en"); err != nil {
+		t.Fatal(err)
+	}
+	if l.Lines[2].Translation != "NA NA" || l.Lines[2].Text != "na na" || !l.Translated() {
+		t.Errorf("got lines %+v", l.Lines)
+	}
+}
+
+func TestTranslatorChain(t *testing.T) {
+	c := TranslatorChain{&translatorStub{err: ErrNoTranslation}, &translatorStub{}}
+	got, err := c.Translate(context.Background(), Query{}, []string{"a"}, "pl")
+	if err != nil || got[0] != "A" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	c = c[:1]
+	if _, err = c.Translate(context.Background(), Query{}, []string{"a"}, "pl"); err != ErrNoTranslation {
+		t.Errorf("expected ErrNoTranslation got %v", err)
+	}
+}
+
+func TestTekstowoTranslator(t *testing.T) {
+	server := tekstowoServer("tekstowo_song.html")
+	defer server.Close()
+	tr := TekstowoTranslator{Fetcher: TekstowoFetcher{BaseURL: server.URL}}
+	q := Query{Artists: []string{"Queen"}, Title: "Bohemian Rhapsody"}
+	lines := []string{"Is this the real life?", "Is this just fantasy?", "", "Caught in a landslide,"}
+
+	got, err := tr.Translate(context.Background(), q, lines, "pl")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	expected := []string{"Czy to prawdziwe życie?", "Czy to tylko fantazja?", "", "Złapany w lawinę,"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+	if _, err = tr.Translate(context.Background(), q, lines, "de"); err != ErrNoTranslation {
+		t.Errorf("expected ErrNoTranslation for german got %v", err)
+	}
+
+	old := tekstowoServer("tekstowo_song_old.html")
+	defer old.Close()
+	tr.Fetcher.BaseURL = old.URL
+	if _, err = tr.Translate(context.Background(), q, lines, "pl"); err != ErrNoTranslation {
+		t.Errorf("expected ErrNoTranslation for the page without translation got %v", err)
+	}
+}
+
+func TestLibreTranslate(t *testing.T) {
+	var req libreTranslateRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/translate" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.APIKey != "key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"Invalid API key"}`))
+			return
+		}
+		resp := libreTranslateResponse{}
+		for _, q := range req.Q {
+			if q == "la la" {
+				resp.TranslatedText = append(resp.TranslatedText, "")
+				continue
+			}
+			resp.TranslatedText = append(resp.TranslatedText, "["+req.Target+"] "+q)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	tr := LibreTranslate{BaseURL: server.URL, APIKey: "key"}
+	got, err := tr.Translate(context.Background(), Query{}, []string{"one", "", "two"}, "de")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if expected := []string{"[de] one", "", "[de] two"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %q expected %q", got, expected)
+	}
+	if req.Source != "auto" || req.Format != "text" || len(req.Q) != 2 {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	got, err = tr.Translate(context.Background(), Query{}, []string{"one", "", "la la", "two"}, "de")
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if expected := []string{"[de] one", "", "", "[de] two"}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("empty translation shifted the lines, got %q expected %q", got, expected)
+	}
+
+	tr.APIKey = "wrong"
+	if _, err = tr.Translate(context.Background(), Query{}, []string{"one"}, "de"); KindOf(err) != ProviderDown {
+		t.Errorf("expected ProviderDown error got %v", err)
+	}
+}
+
+func TestTranslationCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "translations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stub := &translatorStub{}
+	q := Query{Artists: []string{"A"}, Title: "T"}
+	c := NewTranslationCache(stub, dir)
+	c.Translate(context.Background(), q, []string{"la"}, "pl")
+	c.Translate(context.Background(), q, []string{"la"}, "pl")
+	if stub.calls != 1 {
+		t.Errorf("translated %d times expected once", stub.calls)
+	}
+
+	restarted := NewTranslationCache(stub, dir)
+	got, err := restarted.Translate(context.Background(), q, []string{"la"}, "pl")
+	if err != nil || got[0] != "LA" || stub.calls != 1 {
+		t.Errorf("translation not read from disk, got %q, %v", got, err)
+	}
+	restarted.Translate(context.Background(), q, []string{"la"}, "de")
+	if stub.calls != 2 {
+		t.Errorf("other language not translated")
+	}
+
+	stub.err = ErrNoTranslation
+	restarted.Translate(context.Background(), q, []string{"na"}, "pl")
+	if _, err = restarted.Translate(context.Background(), q, []string{"na"}, "pl"); err != ErrNoTranslation || stub.calls != 3 {
+		t.Errorf("missing translation not remembered, got %v after %d calls", err, stub.calls)
+	}
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"time"
 
+	"github.com/gala377/Lyricer/karaoke"
 	"github.com/gala377/Lyricer/lyrics"
 
 	"github.com/gala377/Lyricer/config"
@@ -22,90 +25,108 @@ func main() {
 		log.Fatalf("Error while reading the config file: %s", err)
 		return
 	}
-	spotify := spotify.NewSpotify(conf.Spotify)
-
-	spotCodeChan, err := spotify.Authorize()
-	if err != nil {
-		log.Fatalf("Could not authorize spotify %s", err)
+	if len(os.Args) > 1 {
+		runCommand(conf, os.Args[1], os.Args[2:])
 		return
 	}
-	// todo we can do some work while wainting for the authorization
-	log.Println("Main Reading code")
-	log.Printf("Authorized spotify. Code is: %s\n", <-spotCodeChan)
-
-	log.Println("Main accessing spotify")
-	accessChan, err := spotify.Access()
+	spotify, err := authorize(conf)
 	if err != nil {
-		log.Fatalf("Could not access spotify %s", err)
+		log.Fatalf("%s", err)
 		return
 	}
-	code, ok := <-accessChan
-	if !ok {
-		log.Fatalf("Access channel closed early")
-	}
-	log.Printf("Accessed spotify. Access token is: %s\n", code)
-	f := lyrics.TekstowoFetcher{}
+	f := newFetcher(conf, spotify)
+	tr := newTranslator(conf)
+	ro := newRomanizer(conf)
+	overrides := lyrics.NewOverrides(nil, conf.Lyrics.OverridesDir)
+	view := karaoke.NewView(os.Stdout)
 
 	currPlaying, err := spotify.CurrentlyPlayedSong()
 	if err != nil {
 		log.Fatalf("Couldn't retrieve currently played song %s", err)
 		return
 	}
-	song := lyrics.SongInfo{
-		Author: currPlaying.Artist,
-		Title:  currPlaying.Title,
-	}
-	err = song.FetchLyrics(f)
-	if err != nil {
-		log.Fatalf(
-			"Could not fetch lyrics for the: %s, %s\n reason: %s\n",
-			song.Author,
-			song.Title,
-			err,
-		)
-	}
-	log.Printf("Song: %s, %s\n\n %s\n", song.Author, song.Title, song.Lyrics)
+	song := fetchSong(spotify, f, currPlaying)
+	prepareSong(conf, ro, tr, &song)
+	showSong(view, overrides, song, currPlaying)
 
 	refreshChannel := make(chan bool)
+	offsetChannel := make(chan time.Duration)
+	tapChannel := make(chan bool)
+	versionChannel := make(chan bool)
 	closeChannel := make(chan bool)
 
 	go func() {
+		frames := time.NewTicker(frameInterval)
+		defer frames.Stop()
+		wait := nextPoll(currPlaying, song)
+		log.Printf("Waiting %d for the next poll", wait)
+		poll := time.After(wait)
 	mainLoop:
 		for {
-			log.Printf("Waiting %d for the song end", currPlaying.Left)
+			refresh := false
 			select {
-			case <-time.After(currPlaying.Left):
+			case <-frames.C:
+				if song.Synced != nil {
+					view.Render()
+				}
+				continue
+			case d := <-offsetChannel:
+				// Offsets are saved per track, so the
+				// song starts with it the next time.
+				if err := overrides.SaveOffset(song.TrackID, view.AdjustOffset(d)); err != nil {
+					log.Printf("Could not save the offset: %s", err)
+				}
+				continue
+			case <-tapChannel:
+				if offset, ok := view.Tap(); ok {
+					if err := overrides.SaveOffset(song.TrackID, offset); err != nil {
+						log.Printf("Could not save the offset: %s", err)
+					}
+				}
+				continue
+			case <-versionChannel:
+				if len(song.Versions) < 2 {
+					log.Println("There are no other versions of the lyrics")
+					continue
+				}
+				song.SetVersion((song.Version + 1) % len(song.Versions))
+				prepareSong(conf, ro, tr, &song)
+				showSong(view, overrides, song, currPlaying)
+				// The chosen version is put first the next time.
+				if err := overrides.SaveVersion(song.TrackID, song.Versions[song.Version].Key()); err != nil {
+					log.Printf("Could not save the chosen version: %s", err)
+				}
+				continue
+			case <-poll:
 			case <-refreshChannel:
+				refresh = true
 			case <-closeChannel:
 				break mainLoop
 			}
+			prevID := currPlaying.Track.ID
 			currPlaying, err = spotify.CurrentlyPlayedSong()
 			if err != nil {
 				log.Printf("Couldn't retrieve currently played song %s", err)
 				log.Println("Trying again in 30 seconds")
 				currPlaying.Left = time.Second * 30
-			} else {
-				song = lyrics.SongInfo{
-					Author: currPlaying.Artist,
-					Title:  currPlaying.Title,
-				}
-				err = song.FetchLyrics(f)
-				if err != nil {
-					log.Printf(
-						"Could not fetch lyrics for the: %s, %s\n reason: %s\n",
-						song.Author,
-						song.Title,
-						err,
-					)
+			} else if refresh || currPlaying.Track.ID != prevID {
+				song = fetchSong(spotify, f, currPlaying)
+				prepareSong(conf, ro, tr, &song)
+				showSong(view, overrides, song, currPlaying)
+			} else if song.Synced != nil {
+				if view.Sync(currPlaying.Progress, currPlaying.FetchedAt, currPlaying.IsPlaying) {
+					log.Println("Seek detected, resynced lyrics")
 				}
-				log.Printf("Song: %s, %s\n\n %s\n", song.Author, song.Title, song.Lyrics)
 			}
+			wait = nextPoll(currPlaying, song)
+			log.Printf("Waiting %d for the next poll", wait)
+			poll = time.After(wait)
 		}
 		closeChannel <- true
 	}()
 
 	for {
-		fmt.Println("Q to quit, R to refresh")
+		fmt.Println("Q to quit, R to refresh, + or - to shift synced lyrics, S to tap as a line starts, V to switch the lyrics version, T to toggle translation, O to toggle romanization")
 		reader := bufio.NewReader(os.Stdin)
 		text, _ := reader.ReadString('\n')
 		if text == "r\n" {
@@ -114,6 +135,388 @@ func main() {
 			closeChannel <- true
 			<-closeChannel
 			return
+		} else if text == "+\n" {
+			offsetChannel <- karaoke.OffsetStep
+		} else if text == "-\n" {
+			offsetChannel <- -karaoke.OffsetStep
+		} else if text == "s\n" {
+			tapChannel <- true
+		} else if text == "v\n" {
+			versionChannel <- true
+		} else if text == "t\n" {
+			view.ToggleTranslation()
+		} else if text == "o\n" {
+			view.ToggleRomanization()
 		}
 	}
 }
+
+const (
+	// frameInterval is how often the synced lyrics view is refreshed.
+	frameInterval = 100 * time.Millisecond
+	// syncedPollInterval is how often the player state is polled
+	// while the synced lyrics are shown, so seeks and pauses
+	// are noticed.
+	syncedPollInterval = 5 * time.Second
+	// fetchTimeout is how long the lyrics providers
+	// are given to find the lyrics of the song.
+	fetchTimeout = 20 * time.Second
+)
+
+// nextPoll returns how long to wait before
+// polling the player state again. Synced lyrics
+// are polled often, paused too, so the view
+// follows the player once it's resumed.
+func nextPoll(played spotify.CurrentlyPlayed, song lyrics.SongInfo) time.Duration {
+	if song.Synced != nil && (!played.IsPlaying || played.Left > syncedPollInterval) {
+		return syncedPollInterval
+	}
+	return played.Left
+}
+
+// fetchSong fetches lyrics of the played song,
+// synced ones if the fetcher can provide them.
+func fetchSong(s *spotify.Spotify, f lyrics.Fetcher, played spotify.CurrentlyPlayed) lyrics.SongInfo {
+	song := songInfo(s, played.Track)
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	err := song.FetchContext(ctx, lyrics.Adapt(f))
+	if song.Instrumental {
+		return song
+	}
+	if err != nil {
+		log.Printf(
+			"Could not fetch lyrics for the: %s, %s\n reason: %s\n",
+			song.Author,
+			song.Title,
+			err,
+		)
+	}
+	return song
+}
+
+// prepareSong calibrates the synced lyrics to the track length
+// and applies the transforms, romanization and translation,
+// to the lyrics of the song before they're shown.
+// Synced lyrics get the romanized and translated lines, toggled
+// in the view, plain ones are replaced with the text showing them.
+func prepareSong(conf *config.LyricerConfig, ro lyrics.Romanizer, tr lyrics.Translator, song *lyrics.SongInfo) {
+	if song.Instrumental || (song.Lyrics == "" && song.Synced == nil) {
+		return
+	}
+	if song.Synced != nil && lyrics.Calibrate(song.Synced, song.Duration) {
+		log.Printf("Scaled the synced lyrics timings to the track length %s", song.Duration)
+	}
+	l := song.Synced
+	if l == nil {
+		l = lyrics.PlainLyrics(song.Lyrics)
+	}
+	// Lyrics already in the Latin script or in the
+	// target language are left as they are.
+	if ro != nil && !lyrics.LatinScript(song.Language) {
+		lyrics.Romanize(l, ro)
+	}
+	if tr != nil && song.Language != conf.Translation.Target {
+		translate(conf.Translation.Target, tr, song.Query(), l)
+	}
+	if song.Synced == nil && (l.Translated() || l.Romanized()) {
+		song.Lyrics = karaoke.Text(l)
+	}
+}
+
+// translate translates the lyrics into the target language.
+func translate(target string, tr lyrics.Translator, q lyrics.Query, l *lyrics.Lyrics) {
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+	err := lyrics.Translate(ctx, tr, q, l, target)
+	if err == lyrics.ErrNoTranslation {
+		log.Printf("No %s translation of the lyrics", target)
+	} else if err != nil {
+		log.Printf("Could not translate the lyrics: %s", err)
+	}
+}
+
+// showSong shows the synced lyrics in the view, with the
+// offset saved for the track, or prints the plain ones.
+func showSong(view *karaoke.View, overrides *lyrics.Overrides, song lyrics.SongInfo, played spotify.CurrentlyPlayed) {
+	if song.Provider != "" {
+		log.Printf("Lyrics provided by %s", song.Provider)
+	}
+	if len(song.Versions) > 1 {
+		log.Printf("Lyrics version %d of %d, confidence %.2f", song.Version+1, len(song.Versions), song.Confidence)
+	}
+	if song.Instrumental {
+		log.Printf("Song: %s, %s\n\n ♪ instrumental ♪\n", song.Author, song.Title)
+		return
+	}
+	if song.Synced == nil {
+		log.Printf("Song: %s, %s\n\n %s\n", song.Author, song.Title, song.Lyrics)
+		return
+	}
+	view.SetOffset(overrides.Offset(song.TrackID))
+	view.SetSong(
+		fmt.Sprintf("%s - %s", song.Author, song.Title),
+		song.Synced,
+		played.Progress,
+		played.FetchedAt,
+		played.IsPlaying,
+	)
+}
+
+// authorize returns spotify client with
+// the access token already granted.
+func authorize(conf *config.LyricerConfig) (*spotify.Spotify, error) {
+	spotify := spotify.NewSpotify(conf.Spotify)
+	spotify.SetMarket(conf.Market)
+
+	spotCodeChan, err := spotify.Authorize()
+	if err != nil {
+		return nil, fmt.Errorf("Could not authorize spotify %s", err)
+	}
+	// todo we can do some work while wainting for the authorization
+	log.Println("Main Reading code")
+	log.Printf("Authorized spotify. Code is: %s\n", <-spotCodeChan)
+
+	log.Println("Main accessing spotify")
+	accessChan, err := spotify.Access()
+	if err != nil {
+		return nil, fmt.Errorf("Could not access spotify %s", err)
+	}
+	code, ok := <-accessChan
+	if !ok {
+		return nil, fmt.Errorf("Access channel closed early")
+	}
+	log.Printf("Accessed spotify. Access token is: %s\n", code)
+	return spotify, nil
+}
+
+// providers are the lyrics providers
+// available by name in the config.
+var providers = map[string]func(conf *config.LyricerConfig) lyrics.Fetcher{
+	"tekstowo": func(conf *config.LyricerConfig) lyrics.Fetcher {
+		m := matcher(conf)
+		return lyrics.TekstowoFetcher{Matcher: &m}
+	},
+	"lrclib": func(conf *config.LyricerConfig) lyrics.Fetcher {
+		m := matcher(conf)
+		return lyrics.LRCLibFetcher{
+			BaseURL:  conf.Lyrics.LRCLib.URL,
+			Matcher:  &m,
+			Versions: conf.Lyrics.Versions > 1,
+		}
+	},
+	"genius": func(conf *config.LyricerConfig) lyrics.Fetcher {
+		m := matcher(conf)
+		return lyrics.GeniusFetcher{
+			BaseURL: conf.Lyrics.Genius.URL,
+			Token:   conf.Lyrics.Genius.Token,
+			Matcher: &m,
+		}
+	},
+	"local": func(conf *config.LyricerConfig) lyrics.Fetcher {
+		local := lyrics.NewLocalFetcher(conf.Lyrics.LocalDirs...)
+		if len(conf.Lyrics.LocalTemplates) > 0 {
+			local.Templates = conf.Lyrics.LocalTemplates
+		}
+		return local
+	},
+}
+
+// newFetcher returns lyrics fetcher used by the app
+// and its commands, asking the configured providers.
+// Spotify audio features of the tracks with no lyrics
+// found tell if they are instrumental. Lyrics saved
+// by the user are returned as they are, not cleaned up.
+// Local files are looked up before the cache, so the
+// files added later are not hidden by the cached lyrics.
+func newFetcher(conf *config.LyricerConfig, s *spotify.Spotify) lyrics.Fetcher {
+	chain := &lyrics.Chain{Race: conf.Lyrics.Race, Versions: conf.Lyrics.Versions}
+	var local *lyrics.LocalFetcher
+	for _, name := range conf.Lyrics.Providers {
+		newProvider, ok := providers[name]
+		if !ok {
+			log.Printf("Unknown lyrics provider %s, skipping", name)
+			continue
+		}
+		if name == "local" {
+			local = newProvider(conf).(*lyrics.LocalFetcher)
+			continue
+		}
+		chain.Providers = append(chain.Providers, lyrics.Provider{
+			Name:    name,
+			Fetcher: newProvider(conf),
+		})
+	}
+	if len(chain.Providers) == 0 && local == nil {
+		chain.Providers = []lyrics.Provider{{
+			Name:    "tekstowo",
+			Fetcher: providers["tekstowo"](conf),
+		}}
+	}
+	detector := lyrics.NewInstrumentalDetector(lyrics.NewNormalizer(chain))
+	detector.Threshold = conf.Lyrics.Instrumentalness
+	if conf.Lyrics.Instrumentalness >= 0 {
+		detector.Instrumentalness = func(ctx context.Context, id string) (float64, error) {
+			features, err := s.AudioFeatures(id)
+			return features.Instrumentalness, err
+		}
+	}
+	var fetcher lyrics.Fetcher = newCache(conf, detector)
+	if local != nil {
+		fetcher = lyrics.NewLocalFirst(local, fetcher)
+	}
+	cleaned := &lyrics.Postprocessor{
+		Fetcher:  fetcher,
+		Pipeline: newPipeline(conf),
+	}
+	return lyrics.NewOverrides(cleaned, conf.Lyrics.OverridesDir)
+}
+
+// newPipeline returns the pipeline of the configured
+// transforms cleaning up the lyrics. Unknown transforms
+// and invalid rules are skipped.
+func newPipeline(conf *config.LyricerConfig) lyrics.Pipeline {
+	cleanup := conf.Lyrics.Cleanup
+	names := cleanup.Transforms
+	if len(names) == 0 {
+		names = []string{"entities", "junk", "rules", "whitespace"}
+	}
+	pipeline := lyrics.Pipeline{}
+	for _, name := range names {
+		switch name {
+		case "entities":
+			pipeline = append(pipeline, lyrics.DecodeEntities)
+		case "junk":
+			pipeline = append(pipeline, lyrics.StripJunk)
+		case "headers":
+			pipeline = append(pipeline, lyrics.StripHeaders)
+		case "whitespace":
+			pipeline = append(pipeline, lyrics.NormalizeWhitespace)
+		case "profanity":
+			pipeline = append(pipeline, lyrics.MaskProfanity(cleanup.ProfanityWords...))
+		case "rules":
+			for _, rule := range cleanup.Rules {
+				re, err := regexp.Compile(rule.Pattern)
+				if err != nil {
+					log.Printf("Invalid cleanup rule %q, skipping: %s", rule.Pattern, err)
+					continue
+				}
+				pipeline = append(pipeline, lyrics.RegexRule{Pattern: re, Replacement: rule.Replacement})
+			}
+		default:
+			log.Printf("Unknown lyrics transform %s, skipping", name)
+		}
+	}
+	return pipeline
+}
+
+// translators are the lyrics translators
+// available by name in the config.
+var translators = map[string]func(conf *config.LyricerConfig) lyrics.Translator{
+	"tekstowo": func(conf *config.LyricerConfig) lyrics.Translator {
+		m := matcher(conf)
+		return lyrics.TekstowoTranslator{Fetcher: lyrics.TekstowoFetcher{Matcher: &m}}
+	},
+	"libretranslate": func(conf *config.LyricerConfig) lyrics.Translator {
+		return lyrics.LibreTranslate{
+			BaseURL: conf.Translation.LibreTranslate.URL,
+			APIKey:  conf.Translation.LibreTranslate.Token,
+		}
+	},
+}
+
+// newTranslator returns the cached chain of the configured
+// translators or nil if the translations are disabled.
+func newTranslator(conf *config.LyricerConfig) lyrics.Translator {
+	if conf.Translation.Target == "" {
+		return nil
+	}
+	chain := lyrics.TranslatorChain{}
+	for _, name := range conf.Translation.Translators {
+		newTranslator, ok := translators[name]
+		if !ok {
+			log.Printf("Unknown translator %s, skipping", name)
+			continue
+		}
+		chain = append(chain, newTranslator(conf))
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+	return lyrics.NewTranslationCache(chain, conf.Translation.CacheDir)
+}
+
+// newRomanizer returns the romanizer of the built-in
+// transliteration tables and the configured command
+// romanizing Japanese, or nil if it's disabled.
+func newRomanizer(conf *config.LyricerConfig) lyrics.Romanizer {
+	if !conf.Romanization.Enabled {
+		return nil
+	}
+	ro := lyrics.Romanizers{lyrics.DefaultRomanizer}
+	if len(conf.Romanization.JapaneseCommand) > 0 {
+		ro = append(ro, lyrics.CommandRomanizer{
+			Command: conf.Romanization.JapaneseCommand,
+			Scripts: lyrics.JapaneseScripts,
+		})
+	}
+	return ro
+}
+
+// matcher returns the search results matcher
+// with the configured threshold.
+func matcher(conf *config.LyricerConfig) lyrics.Matcher {
+	if conf.Lyrics.MatchThreshold > 0 {
+		return lyrics.Matcher{Threshold: conf.Lyrics.MatchThreshold}
+	}
+	return lyrics.DefaultMatcher
+}
+
+// newCache returns lyrics cache configured in the conf.
+func newCache(conf *config.LyricerConfig, f lyrics.Fetcher) *lyrics.Cache {
+	cacheConf := conf.Lyrics.Cache
+	cache := lyrics.NewCache(f, cacheConf.Dir)
+	if cacheConf.MaxEntries > 0 {
+		cache.MaxEntries = cacheConf.MaxEntries
+	}
+	cache.TTL = parseDuration(cacheConf.TTL, cache.TTL)
+	cache.NegativeTTL = parseDuration(cacheConf.NegativeTTL, cache.NegativeTTL)
+	return cache
+}
+
+// parseDuration parses the config duration
+// returning def if it's empty or invalid.
+func parseDuration(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Printf("Invalid duration %q in config, using %s", s, def)
+		return def
+	}
+	return d
+}
+
+// songInfo returns SongInfo to look the tracks lyrics up with.
+// Relinked tracks are looked up using the original track
+// metadata as the relinked one can have a different title.
+func songInfo(s *spotify.Spotify, t spotify.Track) lyrics.SongInfo {
+	original, err := s.Original(t)
+	if err != nil {
+		log.Printf("Couldn't retrieve original of the relinked track %s: %s", t.ID, err)
+		original = t
+	}
+	return lyrics.SongInfo{
+		TrackID:  t.ID,
+		Author:   original.Artist(),
+		Title:    original.Title,
+		Album:    original.Album,
+		Duration: original.Duration,
+		Artists:  original.Artists,
+		ISRC:     original.ISRC,
+		// Title in its own script, like Hangul,
+		// tells the language of the lyrics.
+		ExpectedLanguage: lyrics.ScriptLanguage(original.Title),
+	}
+}
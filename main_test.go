@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gala377/Lyricer/lyrics"
+	"github.com/gala377/Lyricer/spotify"
+)
+
+func TestNextPoll(t *testing.T) {
+	synced := lyrics.SongInfo{Synced: lyrics.PlainLyrics("la")}
+	cases := []struct {
+		name     string
+		played   spotify.CurrentlyPlayed
+		song     lyrics.SongInfo
+		expected time.Duration
+	}{
+		{"plain", spotify.CurrentlyPlayed{IsPlaying: true, Left: time.Minute}, lyrics.SongInfo{}, time.Minute},
+		{"synced", spotify.CurrentlyPlayed{IsPlaying: true, Left: time.Minute}, synced, syncedPollInterval},
+		{"synced ending", spotify.CurrentlyPlayed{IsPlaying: true, Left: time.Second}, synced, time.Second},
+		{"synced paused", spotify.CurrentlyPlayed{Left: time.Minute}, synced, syncedPollInterval},
+		{"synced paused ending", spotify.CurrentlyPlayed{Left: time.Second}, synced, syncedPollInterval},
+	}
+	for _, c := range cases {
+		if got := nextPoll(c.played, c.song); got != c.expected {
+			t.Errorf("%s: expected %s got %s", c.name, c.expected, got)
+		}
+	}
+}
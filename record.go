@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gala377/Lyricer/config"
+	"github.com/gala377/Lyricer/karaoke"
+	"github.com/gala377/Lyricer/lyrics"
+)
+
+// recordCommand makes the synced lyrics of the currently
+// played song out of its plain lyrics. The user presses
+// enter as each line starts while the song plays and the
+// lines are timed with the spotify player progress.
+// Lyrics are saved as the override of the track or
+// into the local lyrics directory.
+func recordCommand(conf *config.LyricerConfig, args []string) error {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	local := flags.Bool("local", false, "save into the first of the local lyrics dirs instead of the overrides")
+	flags.Parse(args)
+	if *local && len(conf.Lyrics.LocalDirs) == 0 {
+		return fmt.Errorf("local lyrics dirs are not configured")
+	}
+	if !*local && conf.Lyrics.OverridesDir == "" {
+		return fmt.Errorf("overrides dir is not configured, use -local")
+	}
+
+	spot, err := authorize(conf)
+	if err != nil {
+		return err
+	}
+	played, err := spot.CurrentlyPlayedSong()
+	if err != nil {
+		return fmt.Errorf("couldn't retrieve currently played song %s", err)
+	}
+	if played.Track.ID == "" {
+		return fmt.Errorf("nothing is played right now")
+	}
+	song := songInfo(spot, played.Track)
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	err = song.FetchContext(ctx, lyrics.Adapt(newFetcher(conf, spot)))
+	cancel()
+	if err != nil || song.Lyrics == "" {
+		return fmt.Errorf("no lyrics of %s - %s to time, add them with lyricer edit first", song.Author, song.Title)
+	}
+	if song.Synced != nil {
+		log.Printf("Lyrics are synced already by %s, timing them again", song.Provider)
+	}
+
+	// The clock is kept in sync with the player,
+	// so pauses and seeks are followed.
+	var mu sync.Mutex
+	clock := karaoke.NewClock()
+	clock.Reset(played.Progress, played.FetchedAt, played.IsPlaying)
+	done := make(chan bool)
+	defer close(done)
+	go func() {
+		poll := time.NewTicker(syncedPollInterval)
+		defer poll.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-poll.C:
+			}
+			current, err := spot.CurrentlyPlayedSong()
+			if err != nil {
+				log.Printf("Couldn't retrieve currently played song %s", err)
+				continue
+			}
+			if current.Track.ID != played.Track.ID {
+				log.Printf("Other song is played now, recording goes on")
+			}
+			mu.Lock()
+			clock.Sync(current.Progress, current.FetchedAt, current.IsPlaying)
+			mu.Unlock()
+		}
+	}()
+
+	rec := karaoke.NewRecorder(lyrics.PlainLyrics(song.Lyrics))
+	fmt.Printf("Timing %s - %s, best started from the beginning of the song.\n", song.Author, song.Title)
+	fmt.Println("Enter as the line starts, B for the break after the line, U to undo, Q to quit without saving")
+	reader := bufio.NewReader(os.Stdin)
+	for !rec.Done() {
+		next, _ := rec.Next()
+		marked, all := rec.Marked()
+		fmt.Printf("(%d/%d) next: %s\n", marked+1, all, next)
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		progress := clock.Now()
+		mu.Unlock()
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "":
+			line := rec.Mark(progress)
+			fmt.Printf("%7.2fs %s\n", line.Time.Seconds(), line.Text)
+		case "b":
+			line := rec.Break(progress)
+			fmt.Printf("%7.2fs (break)\n", line.Time.Seconds())
+		case "u":
+			rec.Undo()
+		case "q":
+			return fmt.Errorf("recording quit, lyrics not saved")
+		}
+	}
+
+	l := rec.Lyrics()
+	l.Tags[lyrics.TagArtist] = song.Author
+	l.Tags[lyrics.TagTitle] = song.Title
+	if song.Album != "" {
+		l.Tags[lyrics.TagAlbum] = song.Album
+	}
+	if song.Duration > 0 {
+		l.SetLength(song.Duration)
+	}
+	if *local {
+		f := lyrics.NewLocalFetcher(conf.Lyrics.LocalDirs...)
+		if len(conf.Lyrics.LocalTemplates) > 0 {
+			f.Templates = conf.Lyrics.LocalTemplates
+		}
+		path, err := f.Save(song.Query(), l)
+		if err != nil {
+			return err
+		}
+		// Lyrics fetched before are cached, the saved
+		// ones are used from now on instead.
+		if _, err = newCache(conf, nil).PurgeTrack(song.Query()); err != nil {
+			log.Printf("Could not purge the cached lyrics: %s", err)
+		}
+		fmt.Printf("Saved synced lyrics to %s\n", path)
+		return nil
+	}
+	if err = lyrics.NewOverrides(nil, conf.Lyrics.OverridesDir).Save(song.TrackID, l); err != nil {
+		return err
+	}
+	fmt.Printf("Saved synced lyrics of %s - %s\n", song.Author, song.Title)
+	return nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gala377/Lyricer/config"
+)
+
+// searchCommand searches spotify for the query and
+// prints lyrics of the top track. The lyrics are fetched
+// using spotify canonical artist and title so the
+// query can be misspelled.
+func searchCommand(conf *config.LyricerConfig, args []string) error {
+	flags := flag.NewFlagSet("search", flag.ExitOnError)
+	market := flags.String("market", "", "ISO 3166-1 alpha-2 country code or from_token")
+	flags.Parse(args)
+	query := strings.Join(flags.Args(), " ")
+	if query == "" {
+		return fmt.Errorf("expected search query")
+	}
+
+	spotify, err := authorize(conf)
+	if err != nil {
+		return err
+	}
+	track, err := spotify.SearchTrack(query, *market)
+	if err != nil {
+		return err
+	}
+	song := songInfo(spotify, track)
+	if err = song.FetchLyrics(newFetcher(conf, spotify)); err != nil {
+		return fmt.Errorf("could not fetch lyrics for %s - %s: %s", song.Author, song.Title, err)
+	}
+	fmt.Printf("%s - %s\n\n%s\n", song.Author, song.Title, song.Lyrics)
+	return nil
+}
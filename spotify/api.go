@@ -0,0 +1,136 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// apiURL is the root of the spotify web api.
+const apiURL = "https://api.spotify.com/v1"
+
+const (
+	// maxRateLimitRetries is how many times rate limited
+	// request is retried before giving up.
+	maxRateLimitRetries = 3
+	// defaultRetryAfter is used if the rate limited
+	// response has no valid Retry-After header.
+	defaultRetryAfter = time.Second
+)
+
+// ResponseError is returned by the api calls
+// if the spotify service responded with
+// a non 2xx status code.
+type ResponseError struct {
+	// Code is the original responses code.
+	Code int
+	// Body is the original responses body.
+	Body []byte
+	// RetryAfter is how long spotify asked to wait
+	// before the next request. Set only if the
+	// request was rate limited.
+	RetryAfter time.Duration
+}
+
+func (err *ResponseError) Error() string {
+	return fmt.Sprintf(
+		"Spotify response with Code: %d Body: %s", err.Code, err.Body)
+}
+
+// endpoint returns full url of the given api path
+// with the query parameters encoded.
+func (s *Spotify) endpoint(path string, query url.Values) string {
+	base := s.baseURL
+	if base == "" {
+		base = apiURL
+	}
+	if len(query) == 0 {
+		return base + path
+	}
+	return base + path + "?" + query.Encode()
+}
+
+// marketQuery returns query with the market
+// parameter added if the market is set.
+func (s *Spotify) marketQuery(query url.Values) url.Values {
+	if s.market == "" {
+		return query
+	}
+	withMarket := url.Values{"market": {s.market}}
+	for k, v := range query {
+		withMarket[k] = v
+	}
+	return withMarket
+}
+
+// get makes authorized GET request to the given
+// url and returns the response body.
+//
+// Responses with the status code other than 2xx
+// are returned as *ResponseError.
+func (s *Spotify) get(rawURL string) ([]byte, error) {
+	return s.getContext(context.Background(), rawURL)
+}
+
+// getContext is get that can be cancelled with ctx.
+//
+// Rate limited requests are retried after
+// the time requested by spotify.
+func (s *Spotify) getContext(ctx context.Context, rawURL string) ([]byte, error) {
+	for retry := 0; ; retry++ {
+		body, err := s.doGet(ctx, rawURL)
+		respErr, ok := err.(*ResponseError)
+		if !ok || respErr.Code != http.StatusTooManyRequests || retry == maxRateLimitRetries {
+			return body, err
+		}
+		log.Printf("Rate limited, retrying in %s", respErr.RetryAfter)
+		select {
+		case <-time.After(respErr.RetryAfter):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *Spotify) doGet(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.accessToken))
+	client := http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &ResponseError{
+			Code:       resp.StatusCode,
+			Body:       body,
+			RetryAfter: retryAfter(resp),
+		}
+	}
+	return body, nil
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return defaultRetryAfter
+	}
+	return time.Second * time.Duration(seconds)
+}
@@ -0,0 +1,31 @@
+package spotify
+
+import "encoding/json"
+
+// AudioFeatures are the spotify estimates
+// of the track audio characteristics,
+// each from 0.0 to 1.0.
+type AudioFeatures struct {
+	ID string `json:"id"`
+	// Instrumentalness predicts whether the track
+	// contains no vocals, values above 0.5 are
+	// intended to represent instrumental tracks.
+	Instrumentalness float64 `json:"instrumentalness"`
+	// Speechiness detects the spoken words,
+	// values above 0.66 are probably all spoken.
+	Speechiness float64 `json:"speechiness"`
+	Energy      float64 `json:"energy"`
+	Valence     float64 `json:"valence"`
+}
+
+// AudioFeatures returns the audio features
+// of the track with the given id.
+func (s *Spotify) AudioFeatures(id string) (AudioFeatures, error) {
+	body, err := s.get(s.endpoint("/audio-features/"+id, nil))
+	if err != nil {
+		return AudioFeatures{}, err
+	}
+	var f AudioFeatures
+	err = json.Unmarshal(body, &f)
+	return f, err
+}
@@ -0,0 +1,30 @@
+package spotify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAudioFeatures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audio-features/track" {
+			t.Errorf("Unexpected request %s", r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"id": "track", "instrumentalness": 0.934, "speechiness": 0.0412,
+			"energy": 0.35, "valence": 0.12, "tempo": 118.2, "type": "audio_features"}`)
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	f, err := s.AudioFeatures("track")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Instrumentalness != 0.934 || f.Speechiness != 0.0412 {
+		t.Errorf("Unexpected features %+v", f)
+	}
+}
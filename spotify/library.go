@@ -0,0 +1,57 @@
+package spotify
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// SavedTrack is a track from the users
+// "Liked Songs" library.
+type SavedTrack struct {
+	Track
+	// AddedAt is the time the track was saved.
+	AddedAt time.Time
+}
+
+// SavedTracks streams the users saved tracks newest first,
+// stopping at the first track saved at or before since.
+// Zero since streams the whole library.
+//
+// After the tracks channel is closed the error channel
+// receives the reason the paging stopped, nil on success.
+//
+// Needs the user-library-read scope.
+func (s *Spotify) SavedTracks(ctx context.Context, since time.Time) (<-chan SavedTrack, <-chan error) {
+	out := make(chan SavedTrack)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(out)
+		p := s.Paginate(ctx, "/me/tracks", s.marketQuery(url.Values{"limit": {"50"}}), OffsetPaging)
+		for p.Next() {
+			var item struct {
+				AddedAt time.Time     `json:"added_at"`
+				Track   *spotifyTrack `json:"track"`
+			}
+			if err := p.Decode(&item); err != nil {
+				errc <- err
+				return
+			}
+			if !item.AddedAt.After(since) {
+				break
+			}
+			if item.Track == nil {
+				continue
+			}
+			select {
+			case out <- SavedTrack{Track: item.Track.track(), AddedAt: item.AddedAt}:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+		errc <- p.Err()
+	}()
+	return out, errc
+}
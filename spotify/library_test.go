@@ -0,0 +1,38 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSavedTracksSince(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/me/tracks" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"items": [
+			{"added_at": "2020-03-03T00:00:00Z", "track": {"id": "3", "name": "Three"}},
+			{"added_at": "2020-02-02T00:00:00Z", "track": {"id": "2", "name": "Two"}},
+			{"added_at": "2020-01-01T00:00:00Z", "track": {"id": "1", "name": "One"}}
+		], "total": 3}`)
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	since := time.Date(2020, 2, 2, 0, 0, 0, 0, time.UTC)
+	tracks, errc := s.SavedTracks(context.Background(), since)
+	ids := ""
+	for t := range tracks {
+		ids += t.ID
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if ids != "3" {
+		t.Errorf("Got tracks %q, expected only the one saved after since", ids)
+	}
+}
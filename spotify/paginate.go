@@ -0,0 +1,214 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// PagingStyle is the way the collection
+// endpoint pages through its items.
+type PagingStyle int
+
+const (
+	// OffsetPaging pages using the offset and limit
+	// query parameters, like the playlist tracks.
+	OffsetPaging PagingStyle = iota
+	// CursorPaging pages using the after cursor,
+	// like the recently played tracks.
+	CursorPaging
+)
+
+// page is the paging object returned by
+// the spotify collection endpoints.
+type page struct {
+	Items   []json.RawMessage `json:"items"`
+	Next    string            `json:"next"`
+	Total   int               `json:"total"`
+	Offset  int               `json:"offset"`
+	Limit   int               `json:"limit"`
+	Cursors struct {
+		After string `json:"after"`
+	} `json:"cursors"`
+}
+
+// Paginator iterates over items of a spotify
+// collection endpoint fetching one page at a time,
+// so the whole collection is never held in memory.
+//
+// Paginator follows the next url of every page.
+// If the endpoint omits it the next page is requested
+// using the offset or the after cursor, depending
+// on the Paginator style.
+//
+// Example:
+//
+//	p := s.Paginate(ctx, "/me/tracks", nil, spotify.OffsetPaging)
+//	for p.Next() {
+//		var item struct{ Track Track }
+//		p.Decode(&item)
+//	}
+//	if p.Err() != nil {
+//		log.Printf("Paging failed: %s", p.Err())
+//	}
+type Paginator struct {
+	s     *Spotify
+	ctx   context.Context
+	style PagingStyle
+	// key is the name of the field wrapping the paging
+	// object in the response, empty if it is not wrapped.
+	key   string
+	next  string
+	query url.Values
+	path  string
+
+	items []json.RawMessage
+	item  json.RawMessage
+	total int
+	done  bool
+	err   error
+}
+
+// Paginate returns Paginator over the collection
+// endpoint under the given api path.
+// Paging stops once the ctx is cancelled.
+func (s *Spotify) Paginate(ctx context.Context, path string, query url.Values, style PagingStyle) *Paginator {
+	return &Paginator{
+		s:     s,
+		ctx:   ctx,
+		style: style,
+		next:  s.endpoint(path, query),
+		query: query,
+		path:  path,
+		total: -1,
+	}
+}
+
+// Wrapped tells the Paginator that the paging object
+// is returned under the given key of the response,
+// like the "tracks" field of the search results.
+func (p *Paginator) Wrapped(key string) *Paginator {
+	p.key = key
+	return p
+}
+
+// Next advances the Paginator to the next item,
+// fetching the next page if needed.
+// Returns false once all of the items were read
+// or an error occurred.
+func (p *Paginator) Next() bool {
+	for len(p.items) == 0 {
+		if p.done || p.err != nil {
+			return false
+		}
+		p.fetch()
+	}
+	p.item, p.items = p.items[0], p.items[1:]
+	return true
+}
+
+// Item returns raw json of the current item.
+func (p *Paginator) Item() json.RawMessage {
+	return p.item
+}
+
+// Decode unmarshals the current item into v.
+func (p *Paginator) Decode(v interface{}) error {
+	return json.Unmarshal(p.item, v)
+}
+
+// Total returns total number of the items in
+// the collection as reported by spotify.
+// Returns -1 before the first page is fetched or
+// if the endpoint doesn't report it.
+func (p *Paginator) Total() int {
+	return p.total
+}
+
+// Err returns the error which stopped the paging,
+// nil if all of the items were read.
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// Stream returns a channel to which the items are
+// written one by one. Channel is closed after the
+// last item or when paging failed in which case
+// Err returns the reason. Cancel the ctx when
+// stopping to read early, so the paging stops.
+func (p *Paginator) Stream(ctx context.Context) <-chan json.RawMessage {
+	out := make(chan json.RawMessage)
+	go func() {
+		defer close(out)
+		for p.Next() {
+			select {
+			case out <- p.item:
+			case <-ctx.Done():
+				p.err = ctx.Err()
+				return
+			case <-p.ctx.Done():
+				p.err = p.ctx.Err()
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (p *Paginator) fetch() {
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return
+	}
+	body, err := p.s.getContext(p.ctx, p.next)
+	if err != nil {
+		p.err = err
+		return
+	}
+	var pg page
+	if p.key == "" {
+		err = json.Unmarshal(body, &pg)
+	} else {
+		var wrapped map[string]json.RawMessage
+		if err = json.Unmarshal(body, &wrapped); err == nil {
+			err = json.Unmarshal(wrapped[p.key], &pg)
+		}
+	}
+	if err != nil {
+		p.err = err
+		return
+	}
+	p.items = pg.Items
+	if p.style == OffsetPaging {
+		p.total = pg.Total
+	}
+	p.next = p.nextURL(pg)
+	p.done = p.next == "" || len(pg.Items) == 0
+}
+
+// nextURL returns url of the page following pg
+// or an empty string if pg was the last one.
+func (p *Paginator) nextURL(pg page) string {
+	if pg.Next != "" {
+		return pg.Next
+	}
+	query := url.Values{}
+	for k, v := range p.query {
+		query[k] = v
+	}
+	switch p.style {
+	case OffsetPaging:
+		offset := pg.Offset + len(pg.Items)
+		if offset >= pg.Total {
+			return ""
+		}
+		query.Set("offset", strconv.Itoa(offset))
+	case CursorPaging:
+		if pg.Cursors.After == "" {
+			return ""
+		}
+		query.Set("after", pg.Cursors.After)
+	}
+	return p.s.endpoint(p.path, query)
+}
@@ -0,0 +1,113 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOffsetPagingWithoutNext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("offset") {
+		case "":
+			fmt.Fprint(w, `{"items": [1, 2], "offset": 0, "total": 3}`)
+		case "2":
+			fmt.Fprint(w, `{"items": [3], "offset": 2, "total": 3}`)
+		default:
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	p := s.Paginate(context.Background(), "/items", nil, OffsetPaging)
+	sum := 0
+	for item := range p.Stream(context.Background()) {
+		var n int
+		fmt.Sscan(string(item), &n)
+		sum += n
+	}
+	if p.Err() != nil || sum != 6 || p.Total() != 3 {
+		t.Errorf("Got sum %d, total %d, err %v", sum, p.Total(), p.Err())
+	}
+}
+
+func TestCursorPagingWrapped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"artists": {"items": ["a"], "cursors": {"after": "x"}}}`)
+		case "x":
+			fmt.Fprint(w, `{"artists": {"items": ["b"], "cursors": {"after": null}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	p := s.Paginate(context.Background(), "/me/following", nil, CursorPaging).Wrapped("artists")
+	got := ""
+	for p.Next() {
+		var name string
+		p.Decode(&name)
+		got += name
+	}
+	if p.Err() != nil || got != "ab" {
+		t.Errorf("Got %q err %v", got, p.Err())
+	}
+}
+
+func TestPagingRetriesRateLimited(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `{"items": [1], "total": 1}`)
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	p := s.Paginate(context.Background(), "/items", nil, OffsetPaging)
+	n := 0
+	for p.Next() {
+		n++
+	}
+	if p.Err() != nil || n != 1 || calls != 2 {
+		t.Errorf("Got %d items in %d calls, err %v", n, calls, p.Err())
+	}
+}
+
+func TestPagingCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &Spotify{baseURL: "http://127.0.0.1:0"}
+	p := s.Paginate(ctx, "/items", nil, OffsetPaging)
+	if p.Next() || p.Err() != context.Canceled {
+		t.Errorf("Expected cancelled paging, got %v", p.Err())
+	}
+}
+
+func TestStreamStopsWhenCancelled(t *testing.T) {
+	// Endless collection, paging stops only when cancelled.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": [1], "cursors": {"after": "x"}}`)
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	p := s.Paginate(context.Background(), "/items", nil, CursorPaging)
+	ctx, cancel := context.WithCancel(context.Background())
+	items := p.Stream(ctx)
+	<-items
+	cancel()
+	for range items {
+	}
+	if p.Err() != context.Canceled {
+		t.Errorf("Expected context.Canceled got %v", p.Err())
+	}
+}
@@ -0,0 +1,45 @@
+package spotify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCurrentlyPlayedRelinked(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/me/player/currently-playing":
+			if r.URL.Query().Get("market") != "from_token" {
+				t.Errorf("Market not sent: %s", r.URL)
+			}
+			fmt.Fprint(w, `{"progress_ms": 1000, "is_playing": true, "item": {
+				"id": "relinked", "name": "Song - 2011 Remaster", "artists": [{"name": "A"}],
+				"duration_ms": 3000, "is_playable": true, "linked_from": {"id": "original"}}}`)
+		case "/tracks/original":
+			if r.URL.Query().Get("market") != "" {
+				t.Errorf("Original track requested with market: %s", r.URL)
+			}
+			fmt.Fprint(w, `{"id": "original", "name": "Song", "artists": [{"name": "A"}], "is_playable": false}`)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	s.SetMarket("from_token")
+	played, err := s.CurrentlyPlayedSong()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if played.Track.LinkedFrom != "original" || !played.Track.IsPlayable || played.Left.Seconds() != 2 {
+		t.Fatalf("Unexpected played track %+v", played)
+	}
+	original, err := s.Original(played.Track)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if original.Title != "Song" || original.IsPlayable {
+		t.Errorf("Unexpected original track %+v", original)
+	}
+}
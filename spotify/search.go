@@ -0,0 +1,164 @@
+package spotify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ErrNoResults is returned by SearchTrack if
+// the search returned no tracks.
+var ErrNoResults = errors.New("search returned no results")
+
+// SearchType is the type of the object
+// to search for.
+type SearchType string
+
+// Search types supported by Search.
+const (
+	SearchTypeTrack  SearchType = "track"
+	SearchTypeAlbum  SearchType = "album"
+	SearchTypeArtist SearchType = "artist"
+)
+
+// Album represents spotify album
+// as returned by the search.
+type Album struct {
+	ID          string
+	Name        string
+	Artists     []string
+	ReleaseDate string
+	TotalTracks int
+}
+
+// Artist represents spotify artist
+// as returned by the search.
+type Artist struct {
+	ID         string
+	Name       string
+	Genres     []string
+	Popularity int
+}
+
+// SearchResults holds the first page of results
+// of every searched type, ordered by spotify relevance.
+type SearchResults struct {
+	Tracks  []Track
+	Albums  []Album
+	Artists []Artist
+}
+
+// searchLimit is the number of results
+// of every type returned by Search.
+const searchLimit = 10
+
+// Search searches spotify catalog for the objects
+// of the given types matching the query.
+// If types is empty all of the supported types are searched.
+//
+// market is an ISO 3166-1 alpha-2 country code or
+// "from_token". If it's empty the market set with
+// SetMarket is used.
+func (s *Spotify) Search(query string, types []SearchType, market string) (SearchResults, error) {
+	if len(types) == 0 {
+		types = []SearchType{SearchTypeTrack, SearchTypeAlbum, SearchTypeArtist}
+	}
+	typeNames := make([]string, len(types))
+	for i, t := range types {
+		typeNames[i] = string(t)
+	}
+	params := url.Values{
+		"q":     {query},
+		"type":  {strings.Join(typeNames, ",")},
+		"limit": {strconv.Itoa(searchLimit)},
+	}
+	if market != "" {
+		params.Set("market", market)
+	} else {
+		params = s.marketQuery(params)
+	}
+	body, err := s.get(s.endpoint("/search", params))
+	if err != nil {
+		return SearchResults{}, err
+	}
+	return parseSearchResults(body)
+}
+
+// SearchTrack returns the most relevant track
+// matching the query.
+func (s *Spotify) SearchTrack(query, market string) (Track, error) {
+	results, err := s.Search(query, []SearchType{SearchTypeTrack}, market)
+	if err != nil {
+		return Track{}, err
+	}
+	if len(results.Tracks) == 0 {
+		return Track{}, ErrNoResults
+	}
+	return results.Tracks[0], nil
+}
+
+func parseSearchResults(body []byte) (SearchResults, error) {
+	var resp struct {
+		Tracks struct {
+			Items []*spotifyTrack `json:"items"`
+		} `json:"tracks"`
+		Albums struct {
+			Items []*struct {
+				ID      string `json:"id"`
+				Name    string `json:"name"`
+				Artists []struct {
+					Name string `json:"name"`
+				} `json:"artists"`
+				ReleaseDate string `json:"release_date"`
+				TotalTracks int    `json:"total_tracks"`
+			} `json:"items"`
+		} `json:"albums"`
+		Artists struct {
+			Items []*struct {
+				ID         string   `json:"id"`
+				Name       string   `json:"name"`
+				Genres     []string `json:"genres"`
+				Popularity int      `json:"popularity"`
+			} `json:"items"`
+		} `json:"artists"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return SearchResults{}, err
+	}
+	// Spotify may return null items in place
+	// of the objects unavailable in the market.
+	results := SearchResults{}
+	for _, t := range resp.Tracks.Items {
+		if t != nil {
+			results.Tracks = append(results.Tracks, t.track())
+		}
+	}
+	for _, a := range resp.Albums.Items {
+		if a == nil {
+			continue
+		}
+		album := Album{
+			ID:          a.ID,
+			Name:        a.Name,
+			ReleaseDate: a.ReleaseDate,
+			TotalTracks: a.TotalTracks,
+		}
+		for _, artist := range a.Artists {
+			album.Artists = append(album.Artists, artist.Name)
+		}
+		results.Albums = append(results.Albums, album)
+	}
+	for _, a := range resp.Artists.Items {
+		if a != nil {
+			results.Artists = append(results.Artists, Artist{
+				ID:         a.ID,
+				Name:       a.Name,
+				Genres:     a.Genres,
+				Popularity: a.Popularity,
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package spotify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("q") != "bohemian rapsody" || q.Get("type") != "track,artist" || q.Get("market") != "PL" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		fmt.Fprint(w, `{
+			"tracks": {"items": [null, {"id": "t", "name": "Bohemian Rhapsody", "artists": [{"name": "Queen"}], "album": {"name": "A Night at the Opera"}}]},
+			"artists": {"items": [{"id": "a", "name": "Queen", "genres": ["rock"], "popularity": 80}]}
+		}`)
+	}))
+	defer srv.Close()
+
+	s := &Spotify{baseURL: srv.URL}
+	results, err := s.Search("bohemian rapsody", []SearchType{SearchTypeTrack, SearchTypeArtist}, "PL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Tracks) != 1 || results.Tracks[0].Artist() != "Queen" || results.Tracks[0].Title != "Bohemian Rhapsody" {
+		t.Errorf("Unexpected tracks %+v", results.Tracks)
+	}
+	if len(results.Artists) != 1 || results.Artists[0].Genres[0] != "rock" {
+		t.Errorf("Unexpected artists %+v", results.Artists)
+	}
+	if len(results.Albums) != 0 {
+		t.Errorf("Unexpected albums %+v", results.Albums)
+	}
+}
@@ -45,6 +45,14 @@ type CurrentlyPlayed struct {
 	Left time.Duration
 	// Is the song playing or is it paused.
 	IsPlaying bool
+	// Track is the full model of the played track.
+	Track Track
+	// Progress is the playback position
+	// in the song at the FetchedAt moment.
+	Progress time.Duration
+	// FetchedAt is the moment the player
+	// state was received.
+	FetchedAt time.Time
 }
 
 // Spotify handles OAuth communication with
@@ -55,6 +63,10 @@ type Spotify struct {
 	accessToken  string
 	refreshToken string
 	expires      time.Time
+	// market is sent with every track request if set.
+	market string
+	// baseURL overrides apiURL if set.
+	baseURL string
 }
 
 // NewSpotify creates new Spotify struct
@@ -65,6 +77,15 @@ func NewSpotify(conf config.OAuthData) *Spotify {
 	}
 }
 
+// SetMarket sets the market sent with every track
+// request so spotify can relink tracks unavailable
+// in the users country. Market is an ISO 3166-1 alpha-2
+// country code or "from_token" to use the users account
+// country. Empty market disables relinking.
+func (s *Spotify) SetMarket(market string) {
+	s.market = market
+}
+
 // Authorize grants authorization from the user
 // for the spotify service.
 // Access request should follow successful authorization
@@ -154,15 +175,18 @@ func (s *Spotify) CurrentlyPlayedSong() (CurrentlyPlayed, error) {
 	if err != nil {
 		return CurrentlyPlayed{}, err
 	}
+	fetchedAt := time.Now()
 	log.Println("Parsing to currently played object")
-	return s.spotifyResponseToCurrentlyPlayed(respBody)
+	played, err := s.spotifyResponseToCurrentlyPlayed(respBody)
+	played.FetchedAt = fetchedAt
+	return played, err
 
 }
 
 func (s *Spotify) playedSongRequest() (*http.Request, error) {
 	req, err := http.NewRequest(
 		"GET",
-		"https://api.spotify.com/v1/me/player/currently-playing",
+		s.endpoint("/me/player/currently-playing", s.marketQuery(nil)),
 		nil,
 	)
 	if err != nil {
@@ -187,34 +211,32 @@ func (s *Spotify) playedSongResponce(r *http.Request) ([]byte, error) {
 
 func (s *Spotify) spotifyResponseToCurrentlyPlayed(respBody []byte) (CurrentlyPlayed, error) {
 	var relevantRespInfo struct {
-		ProgressMS int `json:"progress_ms"`
-		Item       struct {
-			Artists []struct {
-				Name string `json:"name"`
-			} `json:"artists"`
-			SongTitle  string `json:"name"`
-			DurationMS int    `json:"duration_ms"`
-		}
-		IsPlaying bool `json:"is_playing"`
+		ProgressMS int          `json:"progress_ms"`
+		Item       spotifyTrack `json:"item"`
+		IsPlaying  bool         `json:"is_playing"`
 	}
 	err := json.Unmarshal(respBody, &relevantRespInfo)
 	if err != nil {
 		return CurrentlyPlayed{}, err
 	}
-	if len(relevantRespInfo.Item.Artists) == 0 && relevantRespInfo.Item.SongTitle == "" {
+	if len(relevantRespInfo.Item.Artists) == 0 && relevantRespInfo.Item.Name == "" {
 		return CurrentlyPlayed{}, ErrEmptySongData
 	}
+	track := relevantRespInfo.Item.track()
 
-	timeLeft := time.Millisecond * time.Duration(relevantRespInfo.Item.DurationMS)
+	progress := time.Millisecond * time.Duration(relevantRespInfo.ProgressMS)
+	timeLeft := track.Duration
 	log.Printf("Duration is: %d", timeLeft)
 	log.Printf("Progress is: %d", relevantRespInfo.ProgressMS)
-	timeLeft = timeLeft - time.Millisecond*time.Duration(relevantRespInfo.ProgressMS)
+	timeLeft = timeLeft - progress
 	log.Printf("Left to song end is: %d", timeLeft)
 	return CurrentlyPlayed{
-		Artist:    relevantRespInfo.Item.Artists[0].Name,
-		Title:     relevantRespInfo.Item.SongTitle,
+		Artist:    track.Artist(),
+		Title:     track.Title,
 		Left:      timeLeft,
 		IsPlaying: relevantRespInfo.IsPlaying,
+		Track:     track,
+		Progress:  progress,
 	}, nil
 
 }
@@ -0,0 +1,262 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedURI is returned by ParseURI if the
+// given string is not a spotify playlist or album
+// uri nor link.
+var ErrUnsupportedURI = errors.New("unsupported spotify uri")
+
+// Track represents a single spotify track
+// as returned by the collection endpoints.
+type Track struct {
+	// ID is spotify id of the track.
+	ID string
+	// Title of the track.
+	Title string
+	// Artists of the track in the order
+	// returned by spotify, main artist first.
+	Artists []string
+	// Album the track was released on.
+	Album string
+	// Duration of the track.
+	Duration time.Duration
+	// ISRC is the International Standard Recording Code
+	// of the track, empty if spotify doesn't know it.
+	ISRC string
+	// IsPlayable reports whether the track can be played
+	// in the requested market. Always true if the request
+	// had no market.
+	IsPlayable bool
+	// LinkedFrom is the id of the originally requested
+	// track if spotify relinked it to a different one
+	// available in the market, empty otherwise.
+	LinkedFrom string
+}
+
+// Artist returns the main artist of the track.
+func (t Track) Artist() string {
+	if len(t.Artists) == 0 {
+		return ""
+	}
+	return t.Artists[0]
+}
+
+// Collection is a named, ordered list of tracks
+// like a playlist or an album.
+type Collection struct {
+	Name   string
+	Tracks []Track
+}
+
+// URIKind is the kind of the spotify object
+// the uri is pointing to.
+type URIKind string
+
+// Kinds of the spotify collections supported
+// by the ParseURI.
+const (
+	PlaylistURI URIKind = "playlist"
+	AlbumURI    URIKind = "album"
+)
+
+// ParseURI parses spotify uri in the form of
+// "spotify:playlist:ID" or the open.spotify.com link
+// and returns kind of the object and its id.
+//
+// Example:
+//
+//	kind, id, err := spotify.ParseURI("https://open.spotify.com/album/ID?si=x")
+//	// kind == spotify.AlbumURI, id == "ID"
+func ParseURI(uri string) (URIKind, string, error) {
+	var parts []string
+	if strings.HasPrefix(uri, "spotify:") {
+		parts = strings.Split(strings.TrimPrefix(uri, "spotify:"), ":")
+	} else {
+		link, err := url.Parse(uri)
+		if err != nil {
+			return "", "", err
+		}
+		if link.Host != "open.spotify.com" {
+			return "", "", ErrUnsupportedURI
+		}
+		parts = strings.Split(strings.Trim(link.Path, "/"), "/")
+		// Localized links look like open.spotify.com/intl-pl/album/ID.
+		if len(parts) > 0 && strings.HasPrefix(parts[0], "intl-") {
+			parts = parts[1:]
+		}
+	}
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", ErrUnsupportedURI
+	}
+	kind := URIKind(parts[0])
+	if kind != PlaylistURI && kind != AlbumURI {
+		return "", "", ErrUnsupportedURI
+	}
+	return kind, parts[1], nil
+}
+
+// Collection returns the playlist or album
+// the uri is pointing to with all of its tracks.
+func (s *Spotify) Collection(uri string) (Collection, error) {
+	kind, id, err := ParseURI(uri)
+	if err != nil {
+		return Collection{}, err
+	}
+	if kind == AlbumURI {
+		return s.Album(id)
+	}
+	return s.Playlist(id)
+}
+
+// spotifyTrack is the track object as returned
+// in the api responses.
+type spotifyTrack struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Type is "track", or "episode" for
+	// the podcast episodes in the playlists.
+	Type    string `json:"type"`
+	Artists []struct {
+		Name string `json:"name"`
+	} `json:"artists"`
+	Album struct {
+		Name string `json:"name"`
+	} `json:"album"`
+	DurationMS  int `json:"duration_ms"`
+	ExternalIDs struct {
+		ISRC string `json:"isrc"`
+	} `json:"external_ids"`
+	IsPlayable *bool `json:"is_playable"`
+	LinkedFrom *struct {
+		ID string `json:"id"`
+	} `json:"linked_from"`
+}
+
+func (t spotifyTrack) track() Track {
+	artists := make([]string, 0, len(t.Artists))
+	for _, a := range t.Artists {
+		artists = append(artists, a.Name)
+	}
+	track := Track{
+		ID:         t.ID,
+		Title:      t.Name,
+		Artists:    artists,
+		Album:      t.Album.Name,
+		Duration:   time.Millisecond * time.Duration(t.DurationMS),
+		ISRC:       t.ExternalIDs.ISRC,
+		IsPlayable: t.IsPlayable == nil || *t.IsPlayable,
+	}
+	if t.LinkedFrom != nil {
+		track.LinkedFrom = t.LinkedFrom.ID
+	}
+	return track
+}
+
+// Track returns the track with the given id.
+// The market is not sent, so the track is never relinked.
+func (s *Spotify) Track(id string) (Track, error) {
+	body, err := s.get(s.endpoint("/tracks/"+id, nil))
+	if err != nil {
+		return Track{}, err
+	}
+	var t spotifyTrack
+	if err = json.Unmarshal(body, &t); err != nil {
+		return Track{}, err
+	}
+	return t.track(), nil
+}
+
+// Original returns the originally requested track
+// if t was relinked, t itself otherwise.
+//
+// Relinked tracks can have different titles, like
+// the remastered version of the song, so the lyrics
+// should be looked up using the original.
+func (s *Spotify) Original(t Track) (Track, error) {
+	if t.LinkedFrom == "" || t.LinkedFrom == t.ID {
+		return t, nil
+	}
+	return s.Track(t.LinkedFrom)
+}
+
+// Playlist returns the playlist with the given id
+// and all of its tracks in the playlist order.
+//
+// Local files and podcast episodes are skipped.
+func (s *Spotify) Playlist(id string) (Collection, error) {
+	body, err := s.get(s.endpoint(
+		"/playlists/"+id, url.Values{"fields": {"name"}}))
+	if err != nil {
+		return Collection{}, err
+	}
+	var playlist struct {
+		Name string `json:"name"`
+	}
+	if err = json.Unmarshal(body, &playlist); err != nil {
+		return Collection{}, err
+	}
+	tracks := []Track{}
+	p := s.Paginate(context.Background(), "/playlists/"+id+"/tracks",
+		s.marketQuery(url.Values{"limit": {"100"}}), OffsetPaging)
+	for p.Next() {
+		var item struct {
+			IsLocal bool          `json:"is_local"`
+			Track   *spotifyTrack `json:"track"`
+		}
+		if err = p.Decode(&item); err != nil {
+			return Collection{}, err
+		}
+		if item.IsLocal || item.Track == nil || item.Track.ID == "" {
+			continue
+		}
+		if item.Track.Type != "" && item.Track.Type != "track" {
+			continue
+		}
+		tracks = append(tracks, item.Track.track())
+	}
+	if p.Err() != nil {
+		return Collection{}, p.Err()
+	}
+	return Collection{Name: playlist.Name, Tracks: tracks}, nil
+}
+
+// Album returns the album with the given id
+// and all of its tracks in the album order.
+func (s *Spotify) Album(id string) (Collection, error) {
+	body, err := s.get(s.endpoint("/albums/"+id, nil))
+	if err != nil {
+		return Collection{}, err
+	}
+	var album struct {
+		Name string `json:"name"`
+	}
+	if err = json.Unmarshal(body, &album); err != nil {
+		return Collection{}, err
+	}
+	tracks := []Track{}
+	p := s.Paginate(context.Background(), "/albums/"+id+"/tracks",
+		s.marketQuery(url.Values{"limit": {"50"}}), OffsetPaging)
+	for p.Next() {
+		var item spotifyTrack
+		if err = p.Decode(&item); err != nil {
+			return Collection{}, err
+		}
+		t := item.track()
+		// Album tracks are simplified objects
+		// without the album field.
+		t.Album = album.Name
+		tracks = append(tracks, t)
+	}
+	if p.Err() != nil {
+		return Collection{}, p.Err()
+	}
+	return Collection{Name: album.Name, Tracks: tracks}, nil
+}
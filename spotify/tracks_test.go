@@ -0,0 +1,85 @@
+package spotify
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseURI(t *testing.T) {
+	cases := []struct {
+		uri  string
+		kind URIKind
+		id   string
+		err  error
+	}{
+		{"spotify:playlist:37i9dQZF1DX", PlaylistURI, "37i9dQZF1DX", nil},
+		{"spotify:album:4aawyAB9vmqN3uQ7FjRGTy", AlbumURI, "4aawyAB9vmqN3uQ7FjRGTy", nil},
+		{"https://open.spotify.com/album/4aawy?si=abc", AlbumURI, "4aawy", nil},
+		{"https://open.spotify.com/intl-pl/playlist/37i9", PlaylistURI, "37i9", nil},
+		{"spotify:track:4aawy", "", "", ErrUnsupportedURI},
+		{"https://example.com/album/4aawy", "", "", ErrUnsupportedURI},
+		{"spotify:playlist:", "", "", ErrUnsupportedURI},
+	}
+	for _, c := range cases {
+		kind, id, err := ParseURI(c.uri)
+		if kind != c.kind || id != c.id || err != c.err {
+			t.Errorf("ParseURI(%q) = %q, %q, %v expected %q, %q, %v",
+				c.uri, kind, id, err, c.kind, c.id, c.err)
+		}
+	}
+}
+
+func TestPlaylistPaging(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/playlists/p":
+			fmt.Fprint(w, `{"name": "Party"}`)
+		case "/playlists/p/tracks":
+			if r.URL.Query().Get("offset") == "" {
+				fmt.Fprintf(w, `{"items": [
+					{"track": {"id": "1", "name": "One", "artists": [{"name": "A"}, {"name": "B"}], "album": {"name": "X"}, "duration_ms": 1000}},
+					{"is_local": true, "track": {"id": "", "name": "Local"}},
+					{"track": {"id": "e", "type": "episode", "name": "Podcast", "artists": [{"name": "Host"}]}}
+				], "next": "%s/playlists/p/tracks?offset=3"}`, srv.URL)
+				return
+			}
+			fmt.Fprint(w, `{"items": [{"track": {"id": "2", "name": "Two", "artists": [{"name": "C"}]}}], "next": null}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Spotify{accessToken: "token", baseURL: srv.URL}
+	c, err := s.Collection("spotify:playlist:p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Party" || len(c.Tracks) != 2 {
+		t.Fatalf("Unexpected collection %+v", c)
+	}
+	if c.Tracks[0].Artist() != "A" || c.Tracks[0].Album != "X" || c.Tracks[1].Title != "Two" {
+		t.Errorf("Unexpected tracks %+v", c.Tracks)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	s := &Spotify{accessToken: "token", baseURL: srv.URL}
+	_, err := s.Album("a")
+	respErr, ok := err.(*ResponseError)
+	if !ok || respErr.Code != http.StatusForbidden {
+		t.Errorf("Expected forbidden ResponseError got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gala377/Lyricer/archive"
+	"github.com/gala377/Lyricer/config"
+	"github.com/gala377/Lyricer/lyrics"
+	"github.com/gala377/Lyricer/spotify"
+)
+
+// defaultArchiveDir is used if the config doesn't set one.
+const defaultArchiveDir = "lyrics_archive"
+
+// syncCommand fetches lyrics of the users saved tracks
+// into the local archive. Only tracks saved since the
+// last successful sync are processed, unless -full is set.
+func syncCommand(conf *config.LyricerConfig, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	workers := flags.Int("j", 4, "number of lyrics fetched at once")
+	full := flags.Bool("full", false, "process the whole library, not only tracks saved since the last sync")
+	flags.Parse(args)
+	if *workers < 1 {
+		return fmt.Errorf("-j must be positive")
+	}
+
+	dir := conf.ArchiveDir
+	if dir == "" {
+		dir = defaultArchiveDir
+	}
+	arch, err := archive.Open(dir)
+	if err != nil {
+		return err
+	}
+	state, err := arch.State()
+	if err != nil {
+		return err
+	}
+	since := state.LastAddedAt
+	if *full {
+		since = time.Time{}
+	}
+
+	spot, err := authorize(conf)
+	if err != nil {
+		return err
+	}
+	tracks, err := newSavedTracks(spot, since)
+	if err != nil {
+		return err
+	}
+	// Tracks archived by an interrupted sync are skipped.
+	pending := []spotify.SavedTrack{}
+	for _, t := range tracks {
+		if *full || !arch.Has(t.ID) {
+			pending = append(pending, t)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d new tracks, %d to fetch\n", len(tracks), len(pending))
+
+	missing, failed, err := archiveLyrics(spot, arch, pending, newFetcher(conf, spot), *workers)
+	if err != nil {
+		return err
+	}
+	if len(tracks) > 0 {
+		state.LastAddedAt = syncedUntil(tracks, failed)
+		if err = arch.SetState(state); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Synced %d tracks, %d without lyrics\n", len(pending)-len(failed), missing)
+	if len(failed) > 0 {
+		fmt.Fprintf(os.Stderr, "%d tracks couldn't be fetched now, they are retried by the next sync\n", len(failed))
+	}
+	return nil
+}
+
+// syncedUntil returns the AddedAt the next sync starts after.
+// Tracks come newest first, if some of them failed the next
+// sync starts right before the oldest failed one, so it's
+// fetched again. Archived tracks after it are skipped then.
+func syncedUntil(tracks []spotify.SavedTrack, failed map[string]bool) time.Time {
+	for i := len(tracks) - 1; i >= 0; i-- {
+		if failed[tracks[i].ID] {
+			return tracks[i].AddedAt.Add(-time.Nanosecond)
+		}
+	}
+	return tracks[0].AddedAt
+}
+
+// archiveCommand lists the archived tracks.
+func archiveCommand(conf *config.LyricerConfig, args []string) error {
+	if len(args) == 0 || args[0] != "list" {
+		return fmt.Errorf("expected list")
+	}
+	flags := flag.NewFlagSet("archive list", flag.ExitOnError)
+	lang := flags.String("lang", "", "list only lyrics in the language with the given ISO 639-1 code")
+	missing := flags.Bool("missing", false, "list only tracks without lyrics")
+	flags.Parse(args[1:])
+
+	dir := conf.ArchiveDir
+	if dir == "" {
+		dir = defaultArchiveDir
+	}
+	arch, err := archive.Open(dir)
+	if err != nil {
+		return err
+	}
+	var records []archive.Record
+	if *lang != "" {
+		records, err = arch.RecordsIn(*lang)
+	} else {
+		records, err = arch.Records()
+	}
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tARTIST\tTITLE\tLANG\tLYRICS")
+	listed := 0
+	for _, r := range records {
+		if *missing && !r.Missing() {
+			continue
+		}
+		state := "found"
+		if r.Missing() {
+			state = "missing"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.ID, r.Artist, r.Title, r.Language, state)
+		listed++
+	}
+	fmt.Fprintf(w, "%d tracks\n", listed)
+	return w.Flush()
+}
+
+func newSavedTracks(s *spotify.Spotify, since time.Time) ([]spotify.SavedTrack, error) {
+	tracksChan, errChan := s.SavedTracks(context.Background(), since)
+	tracks := []spotify.SavedTrack{}
+	for t := range tracksChan {
+		tracks = append(tracks, t)
+		fmt.Fprintf(os.Stderr, "\rListing library: %d", len(tracks))
+	}
+	fmt.Fprintln(os.Stderr)
+	return tracks, <-errChan
+}
+
+// archiveLyrics fetches lyrics of the tracks using at most
+// workers goroutines and puts them into the archive.
+// Returns number of tracks which have no lyrics and ids of
+// the tracks which failed for the time being, like when the
+// provider is down or rate limited. Those are not archived,
+// so they are fetched again.
+//
+// Fetcher has to be safe for concurrent use.
+func archiveLyrics(spot *spotify.Spotify, arch *archive.Archive, tracks []spotify.SavedTrack, f lyrics.Fetcher, workers int) (int, map[string]bool, error) {
+	var (
+		mu       sync.Mutex
+		done     int
+		missing  int
+		failed   = map[string]bool{}
+		firstErr error
+	)
+	jobs := make(chan spotify.SavedTrack)
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				song := songInfo(spot, t.Track)
+				r := archive.Record{
+					ID:        t.ID,
+					Artist:    song.Author,
+					Title:     song.Title,
+					Album:     t.Album,
+					AddedAt:   t.AddedAt,
+					FetchedAt: time.Now(),
+				}
+				var err error
+				fetchErr := song.FetchLyrics(f)
+				final := fetchErr == nil || finalError(fetchErr)
+				if fetchErr != nil {
+					r.Error = fetchErr.Error()
+				} else {
+					r.Lyrics = song.Lyrics
+					r.Language, _ = lyrics.DetectLanguage(song.Lyrics)
+				}
+				if final {
+					err = arch.Put(r)
+				}
+
+				mu.Lock()
+				done++
+				if !final {
+					failed[t.ID] = true
+				} else if r.Missing() {
+					missing++
+				}
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				fmt.Fprintf(os.Stderr, "\r\033[K[%d/%d] %s - %s", done, len(tracks), r.Artist, r.Title)
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, t := range tracks {
+		jobs <- t
+	}
+	close(jobs)
+	wg.Wait()
+	if len(tracks) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+	return missing, failed, firstErr
+}
+
+// finalError reports whether the fetch error won't
+// go away with time, so the track can be archived
+// without the lyrics.
+func finalError(err error) bool {
+	kind := lyrics.KindOf(err)
+	return kind == lyrics.NotFound || kind == lyrics.Instrumental
+}
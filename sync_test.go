@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gala377/Lyricer/lyrics"
+	"github.com/gala377/Lyricer/spotify"
+)
+
+func TestSyncedUntil(t *testing.T) {
+	at := func(day int) time.Time { return time.Date(2020, 2, day, 0, 0, 0, 0, time.UTC) }
+	tracks := []spotify.SavedTrack{
+		{AddedAt: at(4), Track: spotify.Track{ID: "a"}},
+		{AddedAt: at(3), Track: spotify.Track{ID: "b"}},
+		{AddedAt: at(2), Track: spotify.Track{ID: "c"}},
+	}
+	cases := []struct {
+		name     string
+		failed   map[string]bool
+		expected time.Time
+	}{
+		{"all synced", map[string]bool{}, at(4)},
+		{"newest failed", map[string]bool{"a": true}, at(4).Add(-time.Nanosecond)},
+		{"oldest failed counts", map[string]bool{"a": true, "c": true}, at(2).Add(-time.Nanosecond)},
+	}
+	for _, c := range cases {
+		if got := syncedUntil(tracks, c.failed); !got.Equal(c.expected) {
+			t.Errorf("%s: expected %s got %s", c.name, c.expected, got)
+		}
+	}
+}
+
+func TestFinalError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{lyrics.ErrLyricsNotFound, true},
+		{&lyrics.Error{Kind: lyrics.Instrumental}, true},
+		{&lyrics.Error{Kind: lyrics.RateLimited, RetryAfter: time.Minute}, false},
+		{&lyrics.Error{Kind: lyrics.ProviderDown}, false},
+		{errors.New("connection reset"), false},
+	}
+	for _, c := range cases {
+		if got := finalError(c.err); got != c.expected {
+			t.Errorf("%v: expected %v got %v", c.err, c.expected, got)
+		}
+	}
+}